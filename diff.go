@@ -0,0 +1,253 @@
+package code
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// diffContext is the number of unchanged lines surrounding the changes of a hunk.
+const diffContext = 3
+
+const (
+	// FileCreated is a file that does not exist yet.
+	FileCreated FileOp = iota + 1
+	// FileUpdated is an existing file whose content changes.
+	FileUpdated
+	// FileDeleted is an existing file that is not generated anymore.
+	FileDeleted
+)
+
+type (
+	FileOp uint8
+
+	// FileDiff is the change of a generated file, as a line-based unified diff.
+	FileDiff struct {
+		Path  string
+		Op    FileOp
+		Hunks []DiffHunk
+	}
+
+	FileDiffs []FileDiff
+
+	// DiffHunk is a group of changed lines with their context; OldStart and NewStart are 1-based.
+	DiffHunk struct {
+		OldStart int
+		OldLines int
+		NewStart int
+		NewLines int
+		// Lines are prefixed with ' ' (context), '-' (removed) or '+' (added); a line missing
+		// its final newline is followed by "\ No newline at end of file".
+		Lines []string
+	}
+
+	diffLine struct {
+		op   diffmatchpatch.Operation
+		text string
+	}
+)
+
+func (o FileOp) String() string {
+	switch o {
+	case FileCreated:
+		return "created"
+	case FileUpdated:
+		return "updated"
+	case FileDeleted:
+		return "deleted"
+	}
+
+	return fmt.Sprintf("FileOp(%d)", uint8(o))
+}
+
+// String returns the diff in the unified format.
+func (d FileDiff) String() string {
+	buf := strings.Builder{}
+	oldName, newName := d.Path, d.Path
+
+	if filepath.IsLocal(filepath.FromSlash(d.Path)) {
+		oldName, newName = "a/"+d.Path, "b/"+d.Path
+	}
+
+	switch d.Op {
+	case FileCreated:
+		oldName = "/dev/null"
+	case FileDeleted:
+		newName = "/dev/null"
+	}
+
+	fmt.Fprintf(&buf, "--- %s\n+++ %s\n", oldName, newName)
+
+	for _, hunk := range d.Hunks {
+		buf.WriteString(hunk.String())
+	}
+
+	return buf.String()
+}
+
+// String returns the concatenated unified diffs.
+func (d FileDiffs) String() string {
+	buf := strings.Builder{}
+
+	for _, itm := range d {
+		buf.WriteString(itm.String())
+	}
+
+	return buf.String()
+}
+
+func (h DiffHunk) String() string {
+	buf := strings.Builder{}
+	fmt.Fprintf(&buf, "@@ -%s +%s @@\n", hunkRange(h.OldStart, h.OldLines), hunkRange(h.NewStart, h.NewLines))
+
+	for _, line := range h.Lines {
+		buf.WriteString(line)
+		buf.WriteByte('\n')
+	}
+
+	return buf.String()
+}
+
+func hunkRange(start int, lines int) string {
+	if lines == 1 {
+		return fmt.Sprint(start)
+	}
+
+	if lines == 0 {
+		// empty ranges refer to the line before the change
+		start--
+	}
+
+	return fmt.Sprintf("%d,%d", start, lines)
+}
+
+// diffFile returns the diff between the old and new content of the file at path.
+func diffFile(path string, op FileOp, old []byte, new []byte) FileDiff {
+	// each distinct line is encoded as a rune, so that the diff is computed line by line
+	lines := []string{}
+	index := make(map[string]rune)
+
+	encode := func(data []byte) []rune {
+		var res []rune
+
+		for _, line := range strings.SplitAfter(string(data), "\n") {
+			if line == "" {
+				continue
+			}
+
+			r, ok := index[line]
+			if !ok {
+				r = lineRune(len(lines))
+				index[line] = r
+				lines = append(lines, line)
+			}
+
+			res = append(res, r)
+		}
+
+		return res
+	}
+
+	oldRunes := encode(old)
+	newRunes := encode(new)
+	diffs := diffmatchpatch.New().DiffMainRunes(oldRunes, newRunes, false)
+
+	all := make([]diffLine, 0)
+
+	for _, itm := range diffs {
+		for _, r := range itm.Text {
+			all = append(all, diffLine{op: itm.Type, text: lines[runeLine(r)]})
+		}
+	}
+
+	return FileDiff{Path: path, Op: op, Hunks: diffHunks(all)}
+}
+
+// lineRune returns the rune encoding the line at idx, skipping the surrogate range that cannot be
+// represented in strings.
+func lineRune(idx int) rune {
+	if idx >= 0xD800 {
+		idx += 0x800
+	}
+
+	return rune(idx)
+}
+
+func runeLine(r rune) int {
+	if r >= 0xE000 {
+		return int(r) - 0x800
+	}
+
+	return int(r)
+}
+
+func diffHunks(all []diffLine) []DiffHunk {
+	var res []DiffHunk
+
+	oldLine, newLine := 1, 1
+
+	for idx := 0; idx < len(all); {
+		if all[idx].op == diffmatchpatch.DiffEqual {
+			oldLine++
+			newLine++
+			idx++
+			continue
+		}
+
+		// a hunk starts with the context preceding the change
+		ctx := min(idx, diffContext)
+		start := idx - ctx
+		hunk := DiffHunk{OldStart: oldLine - ctx, NewStart: newLine - ctx}
+
+		// and extends until the next change is too far away
+		last := idx
+
+		for next := idx + 1; next < len(all); next++ {
+			if all[next].op != diffmatchpatch.DiffEqual {
+				if next-last-1 > 2*diffContext {
+					break
+				}
+
+				last = next
+			}
+		}
+
+		end := min(last+1+diffContext, len(all))
+
+		for _, line := range all[start:end] {
+			switch line.op {
+			case diffmatchpatch.DiffEqual:
+				hunk.Lines = append(hunk.Lines, " "+strings.TrimSuffix(line.text, "\n"))
+				hunk.OldLines++
+				hunk.NewLines++
+			case diffmatchpatch.DiffDelete:
+				hunk.Lines = append(hunk.Lines, "-"+strings.TrimSuffix(line.text, "\n"))
+				hunk.OldLines++
+			case diffmatchpatch.DiffInsert:
+				hunk.Lines = append(hunk.Lines, "+"+strings.TrimSuffix(line.text, "\n"))
+				hunk.NewLines++
+			}
+
+			if !strings.HasSuffix(line.text, "\n") {
+				hunk.Lines = append(hunk.Lines, `\ No newline at end of file`)
+			}
+		}
+
+		for _, line := range all[idx:end] {
+			if line.op != diffmatchpatch.DiffInsert {
+				oldLine++
+			}
+
+			if line.op != diffmatchpatch.DiffDelete {
+				newLine++
+			}
+		}
+
+		res = append(res, hunk)
+		idx = end
+	}
+
+	return res
+}
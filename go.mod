@@ -5,6 +5,12 @@ go 1.23.5
 require (
 	github.com/google/uuid v1.6.0
 	github.com/sergi/go-diff v1.3.1
+	golang.org/x/tools v0.31.0
 )
 
 require gopkg.in/yaml.v3 v3.0.1 // indirect
+
+require (
+	golang.org/x/mod v0.24.0 // indirect
+	golang.org/x/sync v0.12.0 // indirect
+)
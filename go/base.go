@@ -17,10 +17,39 @@ func IsKeyword(s string) bool {
 	return token.IsKeyword(s)
 }
 
+// SymbolFor returns the symbol of type T, importing its package in unit.
 func SymbolFor[T any](unit *Unit) Symbol {
+	res := symbolOf(reflect.TypeFor[T]())
+
+	if res.Path != "" {
+		pkg := unit.Imports.Ensure("", res.Path)
+
+		res.Package = &pkg
+		res.Path = ""
+	}
+
+	return res
+}
+
+// SymbolOf returns the symbol of type T; its package is referenced by import path and imported
+// automatically by the unit the symbol is written into.
+func SymbolOf[T any]() Symbol {
+	return symbolOf(reflect.TypeFor[T]())
+}
+
+// IfaceOf returns the interface T, implemented by a TypeDecl, along with the names of its methods.
+func IfaceOf[T any]() Iface {
 	t := reflect.TypeFor[T]()
+	res := Iface{Type: symbolOf(t)}
+
+	for idx := range t.NumMethod() {
+		res.Meths = append(res.Meths, ID(t.Method(idx).Name))
+	}
 
-	pkg := unit.Imports.Ensure("", t.PkgPath())
+	return res
+}
+
+func symbolOf(t reflect.Type) Symbol {
 	id := t.Name()
 
 	if idx := strings.IndexByte(id, '['); idx > 0 {
@@ -28,8 +57,8 @@ func SymbolFor[T any](unit *Unit) Symbol {
 	}
 
 	return Symbol{
-		Package: &pkg,
-		ID:      ID(id),
+		Path: t.PkgPath(),
+		ID:   ID(id),
 	}
 }
 
@@ -45,6 +74,9 @@ type (
 
 	Symbol struct {
 		Package *PkgRef
+		// Path is the import path of the package declaring the symbol, used when Package is nil.
+		// The unit the symbol is written into imports the package and picks its alias automatically.
+		Path    string
 		ID      ID
 		GenArgs GenArgs
 	}
@@ -68,28 +100,40 @@ func (c Comment) write(w *code.Writer) {
 	}
 }
 
-func (p PkgName) check() {
+func (p PkgName) check() error {
 	if !token.IsIdentifier(string(p)) || string(p) != strings.ToLower(string(p)) {
-		panic(fmt.Errorf("'%s' is not a valid package name", p))
+		return fmt.Errorf("'%s' is not a valid package name", p)
 	}
+
+	return nil
 }
 
 func (p PkgName) write(w *code.Writer) {
-	p.check()
+	if err := p.check(); err != nil {
+		w.Fail(err)
+		return
+	}
+
 	w.WriteString(string(p))
 }
 
 func (i ID) write(w *code.Writer) {
 	if !token.IsIdentifier(string(i)) {
-		panic(fmt.Errorf("'%s' is not a valid identifier", i))
+		w.Fail(fmt.Errorf("'%s' is not a valid identifier", i))
+		return
 	}
 
 	w.WriteString(string(i))
 }
 
 func (p PkgRef) write(w *code.Writer) {
-	p.alias.check()
-	fmt.Fprintf(w, "%s %q", p.alias, p.path)
+	if p.alias == dotImport {
+		w.WriteByte('.')
+	} else {
+		p.alias.write(w)
+	}
+
+	fmt.Fprintf(w, " %q", p.path)
 }
 
 func (s Symbol) simple() bool {
@@ -98,20 +142,33 @@ func (s Symbol) simple() bool {
 
 func (s Symbol) write(w *code.Writer) {
 	if s.Package != nil {
-		s.Package.alias.check()
+		s.checkExported(w, s.Package.path)
+		useImport(w, s.Package.path)
 
-		if !token.IsExported(string(s.ID)) {
-			panic(fmt.Errorf("unexported symbol '%s' while referencing package '%s' (%s)", s.ID, s.Package.alias, s.Package.path))
+		if s.Package.alias != dotImport {
+			s.Package.alias.write(w)
+			w.WriteByte('.')
 		}
+	} else if s.Path != "" {
+		s.checkExported(w, s.Path)
 
-		w.WriteString(string(s.Package.alias))
-		w.WriteByte('.')
+		if alias := importAlias(w, s.Path); alias != dotImport {
+			w.WriteString(string(alias))
+			w.WriteByte('.')
+		}
 	}
 
 	s.ID.write(w)
 	s.GenArgs.write(w)
 }
 
+func (s Symbol) checkExported(w *code.Writer, path string) {
+	// cgo exposes C names as they are
+	if path != cgoPath && !token.IsExported(string(s.ID)) {
+		w.Fail(fmt.Errorf("unexported symbol '%s' while referencing package '%s'", s.ID, path))
+	}
+}
+
 func (a GenArgs) write(w *code.Writer) {
 	if len(a) < 1 {
 		return
@@ -124,16 +181,12 @@ func (a GenArgs) write(w *code.Writer) {
 			w.WriteString(", ")
 		}
 
-		itm.writeType(w)
+		writeType(w, itm, "generic argument requires a type")
 	}
 
 	w.WriteByte(']')
 }
 
-func idString(i ID) string {
-	return writeString(func(w *code.Writer) { i.write(w) })
-}
-
-func writeString(f func(w *code.Writer)) string {
-	return code.WriteString("\t", f)
+func idString(w *code.Writer, i ID) string {
+	return w.Capture(func(w *code.Writer) { i.write(w) })
 }
@@ -0,0 +1,347 @@
+package golang
+
+import (
+	code "github.com/trwk76/go-code"
+)
+
+type (
+	// Value wraps an expression with chainable helpers building larger expressions and statements.
+	// It is an Expr itself; the nodes built from values hold the wrapped expressions.
+	Value struct {
+		expr Expr
+	}
+
+	// CallBuilder builds a CallExpr; see Call.
+	CallBuilder struct {
+		fn      Expr
+		genArgs GenArgs
+	}
+
+	// IfBuilder builds an IfStmt; see If. It is a Stmt itself.
+	IfBuilder struct {
+		stmt IfStmt
+	}
+
+	// FuncBuilder builds a FuncDecl; see Func.
+	FuncBuilder struct {
+		id        ID
+		genParams GenParams
+		params    Params
+		ret       Params
+	}
+
+	// MethBuilder builds a MethDecl; see Method.
+	MethBuilder struct {
+		recv   Param
+		id     ID
+		params Params
+		ret    Params
+	}
+)
+
+var (
+	// Err is the conventional error variable.
+	Err Value = Ident("err")
+)
+
+// V wraps expr into a Value.
+func V(expr Expr) Value {
+	return Value{expr: unwrapExpr(expr)}
+}
+
+// Ident returns the value of the local identifier id.
+func Ident(id ID) Value {
+	return Value{expr: Symbol{ID: id}}
+}
+
+// Call starts a call to fn; the arguments are given by CallBuilder.Args.
+func Call(fn Expr) CallBuilder {
+	return CallBuilder{fn: unwrapExpr(fn)}
+}
+
+// If starts an if statement; the branches are given by IfBuilder.Then and IfBuilder.Else.
+func If(cond Expr) IfBuilder {
+	return IfBuilder{stmt: IfStmt{Cond: unwrapExpr(cond)}}
+}
+
+// Return returns a return statement with the given values.
+func Return(vals ...Expr) ReturnStmt {
+	switch len(vals) {
+	case 0:
+		return ReturnStmt{}
+	case 1:
+		return ReturnStmt{Value: unwrapExpr(vals[0])}
+	}
+
+	return ReturnStmt{Values: unwrapExprs(vals)}
+}
+
+// Block returns a block of the given statements.
+func Block(stmts ...Stmt) BlockStmt {
+	return unwrapStmts(stmts)
+}
+
+// Func starts the declaration of the function id; it ends with FuncBuilder.Body.
+func Func(id ID) FuncBuilder {
+	return FuncBuilder{id: id}
+}
+
+// Method starts the declaration of the method id of recv; it ends with MethBuilder.Body.
+func Method(recv Param, id ID) MethBuilder {
+	return MethBuilder{recv: recv, id: id}
+}
+
+// Expr returns the wrapped expression.
+func (v Value) Expr() Expr {
+	return v.expr
+}
+
+func (v Value) Dot(id ID) Value {
+	return Value{expr: MemberExpr{Value: v.expr, ID: id}}
+}
+
+func (v Value) Call(args ...Expr) Value {
+	return Call(v.expr).Args(args...)
+}
+
+func (v Value) Index(idx Expr) Value {
+	return Value{expr: IndexExpr{Slice: v.expr, Index: unwrapExpr(idx)}}
+}
+
+func (v Value) Assert(t Type) Value {
+	return Value{expr: TypeAssertExpr{Value: v.expr, Type: t}}
+}
+
+func (v Value) Addr() Value {
+	return Value{expr: AddrOfExpr{Op: v.expr}}
+}
+
+func (v Value) Deref() Value {
+	return Value{expr: DerefExpr{Op: v.expr}}
+}
+
+func (v Value) Not() Value {
+	return Value{expr: NotExpr{Op: v.expr}}
+}
+
+func (v Value) Eq(rhs Expr) Value {
+	return Value{expr: EqualExpr{LHS: v.expr, RHS: unwrapExpr(rhs)}}
+}
+
+func (v Value) NotEq(rhs Expr) Value {
+	return Value{expr: NotEqualExpr{LHS: v.expr, RHS: unwrapExpr(rhs)}}
+}
+
+func (v Value) IsNil() Value {
+	return v.Eq(Nil)
+}
+
+func (v Value) NotNil() Value {
+	return v.NotEq(Nil)
+}
+
+func (v Value) LessThan(rhs Expr) Value {
+	return Value{expr: LessThanExpr{LHS: v.expr, RHS: unwrapExpr(rhs)}}
+}
+
+func (v Value) MoreThan(rhs Expr) Value {
+	return Value{expr: MoreThanExpr{LHS: v.expr, RHS: unwrapExpr(rhs)}}
+}
+
+func (v Value) And(rhs Expr) Value {
+	return Value{expr: LogAndExpr{LHS: v.expr, RHS: unwrapExpr(rhs)}}
+}
+
+func (v Value) Or(rhs Expr) Value {
+	return Value{expr: LogOrExpr{LHS: v.expr, RHS: unwrapExpr(rhs)}}
+}
+
+func (v Value) Add(rhs Expr) Value {
+	return Value{expr: AddExpr{LHS: v.expr, RHS: unwrapExpr(rhs)}}
+}
+
+func (v Value) Sub(rhs Expr) Value {
+	return Value{expr: SubtractExpr{LHS: v.expr, RHS: unwrapExpr(rhs)}}
+}
+
+// Assign returns the statement assigning srcs to the value (=).
+func (v Value) Assign(srcs ...Expr) AssignStmt {
+	return AssignStmt{Dests: Exprs{v.expr}, Srcs: unwrapExprs(srcs)}
+}
+
+// Define returns the statement declaring the value, an identifier, from srcs (:=).
+func (v Value) Define(srcs ...Expr) AssignStmt {
+	return AssignStmt{Auto: true, Dests: Exprs{v.expr}, Srcs: unwrapExprs(srcs)}
+}
+
+func (v Value) Inc() IncDecStmt {
+	return IncDecStmt{Expr: v.expr}
+}
+
+func (v Value) Dec() IncDecStmt {
+	return IncDecStmt{Expr: v.expr, Dec: true}
+}
+
+// Stmt returns the statement evaluating the value, usually a call.
+func (v Value) Stmt() ExprStmt {
+	return ExprStmt{Expr: v.expr}
+}
+
+func (v Value) simpleExpr() bool {
+	return v.expr == nil || v.expr.simpleExpr()
+}
+
+func (v Value) precedence() int {
+	return exprPrec(v.expr)
+}
+
+func (v Value) writeExpr(w *code.Writer, singleLine bool) {
+	writeExpr(w, v.expr, singleLine, "value must wrap an expression")
+}
+
+// GenArgs explicitly instantiates the generic function being called.
+func (b CallBuilder) GenArgs(types ...Type) CallBuilder {
+	b.genArgs = types
+	return b
+}
+
+func (b CallBuilder) Args(args ...Expr) Value {
+	return Value{expr: CallExpr{Func: b.fn, GenArgs: b.genArgs, Args: unwrapExprs(args)}}
+}
+
+// Init sets the statement executed before the condition is evaluated.
+func (b IfBuilder) Init(stmt InitStmt) IfBuilder {
+	b.stmt.Init = stmt
+	return b
+}
+
+func (b IfBuilder) Then(stmts ...Stmt) IfBuilder {
+	b.stmt.Then = unwrapStmts(stmts)
+	return b
+}
+
+func (b IfBuilder) Else(stmts ...Stmt) IfBuilder {
+	b.stmt.Else = unwrapStmts(stmts)
+	return b
+}
+
+// ElseIf chains another if statement as the else branch.
+func (b IfBuilder) ElseIf(next IfBuilder) IfBuilder {
+	b.stmt.Else = next.stmt
+	return b
+}
+
+// Stmt returns the built statement.
+func (b IfBuilder) Stmt() IfStmt {
+	return b.stmt
+}
+
+func (b IfBuilder) simpleStmt() bool {
+	return b.stmt.simpleStmt()
+}
+
+func (b IfBuilder) writeStmt(w *code.Writer, singleLine bool) {
+	b.stmt.writeStmt(w, singleLine)
+}
+
+func (b FuncBuilder) GenParams(params ...GenParam) FuncBuilder {
+	b.genParams = params
+	return b
+}
+
+func (b FuncBuilder) Params(params ...Param) FuncBuilder {
+	b.params = params
+	return b
+}
+
+// Returns sets the unnamed result types.
+func (b FuncBuilder) Returns(types ...Type) FuncBuilder {
+	b.ret = resultParams(types)
+	return b
+}
+
+// Body ends the declaration with the given statements.
+func (b FuncBuilder) Body(stmts ...Stmt) FuncDecl {
+	return FuncDecl{ID: b.id, GenParams: b.genParams, Params: b.params, Return: b.ret, Body: unwrapStmts(stmts)}
+}
+
+func (b MethBuilder) Params(params ...Param) MethBuilder {
+	b.params = params
+	return b
+}
+
+// Returns sets the unnamed result types.
+func (b MethBuilder) Returns(types ...Type) MethBuilder {
+	b.ret = resultParams(types)
+	return b
+}
+
+// Body ends the declaration with the given statements.
+func (b MethBuilder) Body(stmts ...Stmt) MethDecl {
+	return MethDecl{Receiver: b.recv, ID: b.id, Params: b.params, Return: b.ret, Body: unwrapStmts(stmts)}
+}
+
+func resultParams(types []Type) Params {
+	res := make(Params, len(types))
+
+	for idx, itm := range types {
+		res[idx] = Param{Type: itm}
+	}
+
+	return res
+}
+
+func unwrapExpr(expr Expr) Expr {
+	if expr == nil {
+		return nil
+	}
+
+	return plain(expr)
+}
+
+func unwrapExprs(exprs []Expr) Exprs {
+	if len(exprs) < 1 {
+		return nil
+	}
+
+	res := make(Exprs, len(exprs))
+
+	for idx, itm := range exprs {
+		res[idx] = unwrapExpr(itm)
+	}
+
+	return res
+}
+
+func unwrapStmts(stmts []Stmt) BlockStmt {
+	res := make(BlockStmt, len(stmts))
+
+	for idx, itm := range stmts {
+		if itm != nil {
+			itm = plain(itm)
+		}
+
+		res[idx] = itm
+	}
+
+	return res
+}
+
+// plain strips the Value and IfBuilder wrappers held anywhere in node.
+func plain[N Node](node N) N {
+	return Rewrite(node, func(n Node) (Node, bool) {
+		switch n := n.(type) {
+		case Value:
+			return n.expr, true
+		case IfBuilder:
+			return n.stmt, true
+		}
+
+		return nil, false
+	})
+}
+
+var (
+	_ Expr = Value{}
+	_ Stmt = IfBuilder{}
+)
@@ -0,0 +1,65 @@
+package golang_test
+
+import (
+	"reflect"
+	"testing"
+
+	code "github.com/trwk76/go-code"
+	golang "github.com/trwk76/go-code/go"
+)
+
+func TestBuilder(t *testing.T) {
+	open := golang.Symbol{Path: "os", ID: "Open"}
+	file := golang.Ident("file")
+
+	decl := golang.Func("Load").
+		Params(golang.Param{ID: "name", Type: golang.String}).
+		Returns(golang.PtrType{Item: golang.Symbol{Path: "os", ID: "File"}}, golang.Error).
+		Body(
+			golang.AssignStmt{Auto: true, Dests: golang.Exprs{file, golang.Err}, Srcs: golang.Exprs{golang.Call(open).Args(golang.Ident("name"))}},
+			golang.If(golang.Err.NotNil()).Then(
+				golang.Return(golang.Nil, golang.Err),
+			),
+			golang.Return(file, golang.Nil),
+		)
+
+	// builders produce the plain nodes
+	expected := golang.BlockStmt{
+		golang.AssignStmt{
+			Auto:  true,
+			Dests: golang.Exprs{golang.Symbol{ID: "file"}, golang.Symbol{ID: "err"}},
+			Srcs:  golang.Exprs{golang.CallExpr{Func: open, Args: golang.Exprs{golang.Symbol{ID: "name"}}}},
+		},
+		golang.IfStmt{
+			Cond: golang.NotEqualExpr{LHS: golang.Symbol{ID: "err"}, RHS: golang.Nil},
+			Then: golang.BlockStmt{golang.ReturnStmt{Values: golang.Exprs{golang.Nil, golang.Symbol{ID: "err"}}}},
+		},
+		golang.ReturnStmt{Values: golang.Exprs{golang.Symbol{ID: "file"}, golang.Nil}},
+	}
+
+	if !reflect.DeepEqual(decl.Body, expected) {
+		t.Errorf("unexpected statements: %#v", decl.Body)
+	}
+
+	unit := golang.Unit{Package: "my", Decls: golang.Decls{golang.FuncDecls{decl}}}
+	text := code.WriteString("\t", func(w *code.Writer) {
+		if err := unit.Write(w); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	if text != `package my
+
+import os "os"
+
+func Load(name string) (*os.File, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+` {
+		t.Errorf("unexpected output:\n%s", text)
+	}
+}
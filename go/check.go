@@ -0,0 +1,143 @@
+package golang
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+
+	code "github.com/trwk76/go-code"
+)
+
+// Check renders the unit, parses the result with go/parser and type-checks it with go/types.
+// Imported packages are resolved through imp; when imp is nil, they are type-checked from their
+// source, as located by go/build in the current module or GOPATH.
+// When problems are found, the returned error is a CheckErrors.
+func (u Unit) Check(imp types.Importer) error {
+	lines := make([]int, len(u.Decls))
+
+	src, err := renderUnit(u, lines)
+	if err != nil {
+		return err
+	}
+
+	fset := token.NewFileSet()
+	fname := string(u.Package) + ".go"
+	res := CheckErrors{}
+
+	file, err := parser.ParseFile(fset, fname, src, parser.AllErrors|parser.ParseComments)
+	if err != nil {
+		var list scanner.ErrorList
+
+		if !errors.As(err, &list) {
+			return err
+		}
+
+		for _, itm := range list {
+			res = append(res, newCheckError(u, lines, itm.Pos, itm.Msg))
+		}
+
+		return res
+	}
+
+	if imp == nil {
+		imp = importer.ForCompiler(fset, "source", nil)
+	}
+
+	cfg := types.Config{
+		Importer: imp,
+		Error: func(err error) {
+			var terr types.Error
+
+			if errors.As(err, &terr) {
+				res = append(res, newCheckError(u, lines, terr.Fset.Position(terr.Pos), terr.Msg))
+			} else {
+				res = append(res, CheckError{DeclIndex: -1, Msg: err.Error()})
+			}
+		},
+	}
+
+	cfg.Check(string(u.Package), fset, []*ast.File{file}, nil)
+
+	if len(res) > 0 {
+		return res
+	}
+
+	return nil
+}
+
+type (
+	// CheckError is a problem reported by Unit.Check.
+	CheckError struct {
+		// Pos is the position of the problem in the rendered unit.
+		Pos token.Position
+		// Decl is the entry of Unit.Decls whose rendering contains Pos; it is nil when the problem
+		// is located in the package clause or in the imports.
+		Decl Decl
+		// DeclIndex is the index of Decl in Unit.Decls or -1.
+		DeclIndex int
+		Msg       string
+	}
+
+	CheckErrors []CheckError
+)
+
+func (e CheckError) Error() string {
+	res := e.Msg
+
+	if e.Pos.IsValid() {
+		res = e.Pos.String() + ": " + res
+	}
+
+	if e.Decl != nil {
+		res += fmt.Sprintf(" (declaration #%d, %T)", e.DeclIndex, e.Decl)
+	}
+
+	return res
+}
+
+func (e CheckErrors) Error() string {
+	items := make([]string, len(e))
+
+	for idx, itm := range e {
+		items[idx] = itm.Error()
+	}
+
+	return strings.Join(items, "\n")
+}
+
+func newCheckError(u Unit, lines []int, pos token.Position, msg string) CheckError {
+	// lines are sorted; find the last declaration starting at or before pos
+	idx := sort.Search(len(lines), func(i int) bool { return lines[i] > pos.Line }) - 1
+
+	res := CheckError{
+		Pos:       pos,
+		DeclIndex: idx,
+		Msg:       msg,
+	}
+
+	if idx >= 0 {
+		res.Decl = u.Decls[idx]
+	}
+
+	return res
+}
+
+func renderUnit(u Unit, declLines []int) (string, error) {
+	buf := strings.Builder{}
+	w := code.NewWriter(&buf, "\t")
+
+	u.write(&w, declLines)
+
+	if err := w.Flush(); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
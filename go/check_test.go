@@ -0,0 +1,50 @@
+package golang_test
+
+import (
+	"errors"
+	"go/importer"
+	"go/token"
+	"testing"
+
+	golang "github.com/trwk76/go-code/go"
+)
+
+func TestCheck(t *testing.T) {
+	imp := importer.ForCompiler(token.NewFileSet(), "source", nil)
+
+	for _, item := range testItems {
+		if err := item.gen().Check(imp); err != nil {
+			t.Errorf("test '%s' failed: %s", item.name, err.Error())
+		}
+	}
+
+	unit := golang.Unit{
+		Package: golang.PkgName("my_test"),
+		Decls: golang.Decls{
+			golang.VarDecls{
+				{ID: "count", Type: golang.Int},
+			},
+			golang.FuncDecls{
+				{
+					ID:     golang.ID("Name"),
+					Return: golang.Params{{Type: golang.String}},
+					Body: golang.BlockStmt{
+						golang.ReturnStmt{Value: golang.Symbol{ID: "count"}},
+					},
+				},
+			},
+		},
+	}
+
+	err := unit.Check(imp)
+
+	var errs golang.CheckErrors
+
+	if !errors.As(err, &errs) || len(errs) != 1 {
+		t.Fatalf("expected a single check error; got: %v", err)
+	}
+
+	if errs[0].DeclIndex != 1 || errs[0].Pos.Line != 6 {
+		t.Errorf("check error reported at wrong position: %s", errs[0].Error())
+	}
+}
@@ -1,7 +1,9 @@
 package golang
 
 import (
+	"errors"
 	"fmt"
+	"slices"
 
 	code "github.com/trwk76/go-code"
 )
@@ -9,13 +11,20 @@ import (
 type (
 	ConstDecl struct {
 		Comment Comment
+		Doc     Doc
 		ID      ID
-		Type    Type
-		Value   Expr
+		// IDs lists the identifiers of a multi-value declaration; it is used instead of ID when not empty.
+		IDs  []ID
+		Type Type
+		// Value is optional; within a ConstDecls section, a missing value repeats the previous one.
+		Value Expr
+		// Values lists the values of a multi-value declaration; it is used instead of Value when not empty.
+		Values Exprs
 	}
 
 	FuncDecl struct {
 		Comment   Comment
+		Doc       Doc
 		ID        ID
 		GenParams GenParams
 		Params    Params
@@ -24,7 +33,10 @@ type (
 	}
 
 	MethDecl struct {
-		Comment  Comment
+		Comment Comment
+		Doc     Doc
+		// Receiver is a parameter whose type is a Symbol, or a pointer to one; the receiver of a method
+		// of a generic type names the type parameters as the GenArgs of the symbol (see TypeDecl.Receiver).
 		Receiver Param
 		ID       ID
 		Params   Params
@@ -34,16 +46,36 @@ type (
 
 	TypeDecl struct {
 		Comment   Comment
+		Doc       Doc
 		ID        ID
 		GenParams GenParams
 		Spec      TypeSpec
+		// Meths are declared after the type; a receiver without a type is given the type itself
+		// (see TypeDecl.Receiver).
+		Meths []MethDecl
+		// Implements lists the interfaces the type is asserted to implement, after its methods.
+		Implements []Iface
+	}
+
+	// Iface is an interface implemented by a type. The assertion uses a pointer to the type if one
+	// of the methods named in Meths has a pointer receiver; all the methods of the type are considered
+	// when Meths is empty.
+	Iface struct {
+		Type  Type
+		Meths []ID
 	}
 
 	VarDecl struct {
 		Comment Comment
+		Doc     Doc
 		ID      ID
-		Type    Type
-		Value   Expr
+		// IDs lists the identifiers of a multi-value declaration; it is used instead of ID when not empty.
+		IDs []ID
+		// Type is optional when the declaration has values.
+		Type  Type
+		Value Expr
+		// Values lists the values of a multi-value declaration; it is used instead of Value when not empty.
+		Values Exprs
 	}
 
 	Decl interface {
@@ -52,6 +84,13 @@ type (
 
 	Decls []Decl
 
+	// RegionDecl writes Decls within a region named Name; once the file is written with code.WriteFile,
+	// the region holds user code and its content is preserved when the file is rewritten.
+	RegionDecl struct {
+		Name  string
+		Decls Decls
+	}
+
 	TypeSpec interface {
 		simpleTypeSpec() bool
 		writeTypeSpec(w *code.Writer)
@@ -67,9 +106,13 @@ type (
 	TypeDecls  []TypeDecl
 	VarDecls   []VarDecl
 
+	// GenParam is a type parameter. A parameter without constraint shares the constraint of the
+	// next one, as in [K, V any].
 	GenParam struct {
 		ID    ID
 		Const GenConst
+		// Union lists the terms of a union constraint (~int | ~string); it is used instead of Const when not empty.
+		Union GenConsts
 	}
 
 	GenParams []GenParam
@@ -82,13 +125,15 @@ type (
 	Param struct {
 		ID   ID
 		Type Type
+		// Variadic makes the last parameter receive any number of Type values (...Type).
+		Variadic bool
 	}
 
 	Params []Param
 
 	declItem interface {
 		simpleDeclItem() bool
-		declItemRow() code.TableRow
+		declItemRow(w *code.Writer) code.TableRow
 		writeDeclItem(w *code.Writer, keyword bool)
 	}
 )
@@ -100,46 +145,64 @@ func (d ConstDecl) simpleDeclItem() bool {
 		res = res && d.Type.simpleType()
 	}
 
-	if d.Value != nil {
-		res = res && d.Value.simpleExpr()
-	}
-
-	return res
+	return res && d.values().simpleExprs()
 }
 
-func (d ConstDecl) declItemRow() code.TableRow {
+func (d ConstDecl) declItemRow(w *code.Writer) code.TableRow {
 	res := code.TableRow{
-		Prefix: commentString(d.Comment),
-		Columns: []string{
-			idString(d.ID),
-			typeString(d.Type, "constant declaration requires a type"),
-		},
+		Prefix:  commentString(w, d.Comment, d.Doc),
+		Columns: []string{idsString(w, d.ids())},
 	}
 
-	if d.Value != nil {
-		res.Columns = append(res.Columns, "= "+exprString(d.Value, ""))
+	if d.Type != nil {
+		res.Columns = append(res.Columns, typeString(w, d.Type, ""))
+	}
+
+	if vals := d.values(); len(vals) > 0 {
+		res.Columns = append(res.Columns, "= "+exprsString(w, vals))
 	}
 
 	return res
 }
 
 func (d ConstDecl) writeDeclItem(w *code.Writer, keyword bool) {
-	d.Comment.write(w)
+	writeComment(w, d.Comment, d.Doc)
 
 	if keyword {
 		w.WriteString("const ")
 	}
 
-	d.ID.write(w)
-	w.Space()
-	d.Type.writeType(w)
+	writeIDs(w, d.ids())
+
+	if d.Type != nil {
+		w.Space()
+		d.Type.writeType(w)
+	}
 
-	if d.Value != nil {
+	if vals := d.values(); len(vals) > 0 {
 		w.WriteString(" = ")
-		d.Value.writeExpr(w, false)
+		writeValues(w, vals, false)
 	}
 }
 
+func (d ConstDecl) ids() []ID {
+	if len(d.IDs) > 0 {
+		return d.IDs
+	}
+
+	return []ID{d.ID}
+}
+
+func (d ConstDecl) values() Exprs {
+	if len(d.Values) > 0 {
+		return d.Values
+	} else if d.Value != nil {
+		return Exprs{d.Value}
+	}
+
+	return nil
+}
+
 func (d FuncDecl) simpleDeclItem() bool {
 	return d.GenParams.simpleGenParams() &&
 		d.Params.simpleParams() &&
@@ -147,19 +210,19 @@ func (d FuncDecl) simpleDeclItem() bool {
 		d.Body.simpleStmt()
 }
 
-func (d FuncDecl) declItemRow() code.TableRow {
+func (d FuncDecl) declItemRow(w *code.Writer) code.TableRow {
 	return code.TableRow{
-		Prefix: commentString(d.Comment),
+		Prefix: commentString(w, d.Comment, d.Doc),
 		Columns: []string{
 			"func",
-			idString(d.ID) + genParamsString(d.GenParams) + paramsString(d.Params, true) + paramsString(d.Return, false),
-			stmtString(d.Body, true, "function declaration requires a body"),
+			idString(w, d.ID) + genParamsString(w, d.GenParams) + paramsString(w, d.Params, true) + paramsString(w, d.Return, false),
+			stmtString(w, d.Body, true, "function declaration requires a body"),
 		},
 	}
 }
 
 func (d FuncDecl) writeDeclItem(w *code.Writer, keyword bool) {
-	d.Comment.write(w)
+	writeComment(w, d.Comment, d.Doc)
 
 	w.WriteString("func ")
 	d.ID.write(w)
@@ -177,23 +240,23 @@ func (d MethDecl) simpleDeclItem() bool {
 		d.Body.simpleStmt()
 }
 
-func (d MethDecl) declItemRow() code.TableRow {
+func (d MethDecl) declItemRow(w *code.Writer) code.TableRow {
 	return code.TableRow{
-		Prefix: commentString(d.Comment),
+		Prefix: commentString(w, d.Comment, d.Doc),
 		Columns: []string{
 			"func",
-			paramsString(Params{d.Receiver}, true),
-			idString(d.ID) + paramsString(d.Params, true) + paramsString(d.Return, false),
-			stmtString(d.Body, true, "method declaration requires a body"),
+			paramsString(w, Params{d.Receiver}, true) + " " + idString(w, d.ID) + paramsString(w, d.Params, true) + paramsString(w, d.Return, false),
+			stmtString(w, d.Body, true, "method declaration requires a body"),
 		},
 	}
 }
 
 func (d MethDecl) writeDeclItem(w *code.Writer, keyword bool) {
-	d.Comment.write(w)
+	writeComment(w, d.Comment, d.Doc)
 
 	w.WriteString("func ")
 	Params{d.Receiver}.write(w, true)
+	w.Space()
 	d.ID.write(w)
 	d.Params.write(w, true)
 	d.Return.write(w, false)
@@ -205,18 +268,18 @@ func (d TypeDecl) simpleDeclItem() bool {
 	return d.GenParams.simpleGenParams() && d.Spec.simpleTypeSpec()
 }
 
-func (d TypeDecl) declItemRow() code.TableRow {
+func (d TypeDecl) declItemRow(w *code.Writer) code.TableRow {
 	return code.TableRow{
-		Prefix: commentString(d.Comment),
+		Prefix: commentString(w, d.Comment, d.Doc),
 		Columns: []string{
-			idString(d.ID) + genParamsString(d.GenParams),
-			typeSpecString(d.Spec),
+			idString(w, d.ID) + genParamsString(w, d.GenParams),
+			typeSpecString(w, d.Spec),
 		},
 	}
 }
 
 func (d TypeDecl) writeDeclItem(w *code.Writer, keyword bool) {
-	d.Comment.write(w)
+	writeComment(w, d.Comment, d.Doc)
 
 	if keyword {
 		w.WriteString("type ")
@@ -228,6 +291,73 @@ func (d TypeDecl) writeDeclItem(w *code.Writer, keyword bool) {
 	writeTypeSpec(w, d.Spec)
 }
 
+// Receiver returns a receiver named id for the methods of the type, a pointer if ptr is set.
+func (d TypeDecl) Receiver(id ID, ptr bool) Param {
+	res := Param{ID: id, Type: d.symbol()}
+
+	if ptr {
+		res.Type = PtrType{Item: res.Type}
+	}
+
+	return res
+}
+
+func (d TypeDecl) symbol() Symbol {
+	res := Symbol{ID: d.ID}
+
+	for _, itm := range d.GenParams {
+		res.GenArgs = append(res.GenArgs, Symbol{ID: itm.ID})
+	}
+
+	return res
+}
+
+// meths returns the methods of the type, with their receivers completed.
+func (d TypeDecl) meths() MethDecls {
+	res := make(MethDecls, len(d.Meths))
+
+	for idx, itm := range d.Meths {
+		if itm.Receiver.Type == nil {
+			itm.Receiver.Type = d.symbol()
+		}
+
+		res[idx] = itm
+	}
+
+	return res
+}
+
+// implementer returns the value used to assert that the type implements iface.
+func (d TypeDecl) implementer(w *code.Writer, iface Iface) Expr {
+	if len(d.GenParams) > 0 {
+		w.Fail(fmt.Errorf("generic type '%s' cannot be asserted to implement an interface", d.ID))
+		return nil
+	}
+
+	typ := d.symbol()
+	ptr := false
+
+	for _, itm := range d.meths() {
+		if _, ok := itm.Receiver.Type.(PtrType); ok && (len(iface.Meths) < 1 || slices.Contains(iface.Meths, itm.ID)) {
+			ptr = true
+		}
+	}
+
+	if !ptr {
+		switch spec := d.Spec.(type) {
+		case StructType, SliceType, MapType:
+			return StructExpr{Type: typ}
+		case Symbol:
+			if zero, ok := builtinZeros[spec.ID]; ok && spec.Package == nil && spec.Path == "" && len(spec.GenArgs) < 1 {
+				return CallExpr{Func: typ, Args: Exprs{zero}}
+			}
+		}
+	}
+
+	// a pointer implements the methods of both receiver kinds
+	return CastExpr{Type: PtrType{Item: typ}, Value: Nil}
+}
+
 func (d VarDecl) simpleDeclItem() bool {
 	res := true
 
@@ -235,79 +365,105 @@ func (d VarDecl) simpleDeclItem() bool {
 		res = res && d.Type.simpleType()
 	}
 
-	if d.Value != nil {
-		res = res && d.Value.simpleExpr()
-	}
-
-	return res
+	return res && d.values().simpleExprs()
 }
 
-func (d VarDecl) declItemRow() code.TableRow {
+func (d VarDecl) declItemRow(w *code.Writer) code.TableRow {
 	res := code.TableRow{
-		Prefix: commentString(d.Comment),
-		Columns: []string{
-			idString(d.ID),
-			typeString(d.Type, "variable declaration requires a type"),
-		},
+		Prefix:  commentString(w, d.Comment, d.Doc),
+		Columns: []string{idsString(w, d.ids())},
 	}
 
-	if d.Value != nil {
-		res.Columns = append(res.Columns, "= "+exprString(d.Value, ""))
+	vals := d.values()
+
+	if d.Type != nil || len(vals) < 1 {
+		res.Columns = append(res.Columns, typeString(w, d.Type, "variable declaration requires a type or a value"))
+	}
+
+	if len(vals) > 0 {
+		res.Columns = append(res.Columns, "= "+exprsString(w, vals))
 	}
 
 	return res
 }
 
 func (d VarDecl) writeDeclItem(w *code.Writer, keyword bool) {
-	d.Comment.write(w)
+	writeComment(w, d.Comment, d.Doc)
 
 	if keyword {
 		w.WriteString("var ")
 	}
 
-	d.ID.write(w)
-	w.Space()
-	d.Type.writeType(w)
+	writeIDs(w, d.ids())
+
+	vals := d.values()
+
+	if d.Type != nil || len(vals) < 1 {
+		w.Space()
+		writeType(w, d.Type, "variable declaration requires a type or a value")
+	}
 
-	if d.Value != nil {
+	if len(vals) > 0 {
 		w.WriteString(" = ")
-		d.Value.writeExpr(w, false)
+		writeValues(w, vals, false)
 	}
 }
 
-func (a TypeAlias) simpleTypeSpec() bool {
-	return a.Target.simpleType()
+func (d VarDecl) ids() []ID {
+	if len(d.IDs) > 0 {
+		return d.IDs
+	}
+
+	return []ID{d.ID}
 }
 
-func (a TypeAlias) writeTypeSpec(w *code.Writer) {
-	w.WriteString("= ")
-	a.Target.writeType(w)
+func (d VarDecl) values() Exprs {
+	if len(d.Values) > 0 {
+		return d.Values
+	} else if d.Value != nil {
+		return Exprs{d.Value}
+	}
+
+	return nil
 }
 
-func commentString(c Comment) string {
-	return writeString(func(w *code.Writer) { c.write(w) })
+func (a TypeAlias) simpleTypeSpec() bool {
+	return a.Target == nil || a.Target.simpleType()
 }
 
-func typeSpecString(s TypeSpec) string {
-	if s == nil {
-		panic(fmt.Errorf("type specifier missing"))
-	}
+func (a TypeAlias) writeTypeSpec(w *code.Writer) {
+	w.WriteString("= ")
+	writeType(w, a.Target, "type alias requires a target type")
+}
 
-	return writeString(func(w *code.Writer) { s.writeTypeSpec(w) })
+func typeSpecString(w *code.Writer, s TypeSpec) string {
+	return w.Capture(func(w *code.Writer) { writeTypeSpec(w, s) })
 }
 
 func writeTypeSpec(w *code.Writer, s TypeSpec) {
 	if s == nil {
-		panic(fmt.Errorf("type specifier missing"))
+		w.Fail(errors.New("type specifier missing"))
+		return
 	}
 
 	s.writeTypeSpec(w)
 }
 
 func (c Comment) writeDecl(w *code.Writer) {
+	w.Newline()
 	c.write(w)
 }
 
+func (d RegionDecl) writeDecl(w *code.Writer) {
+	w.Newline()
+	w.Region(d.Name, func(w *code.Writer) {
+		for _, decl := range d.Decls {
+			decl.writeDecl(w)
+		}
+	})
+	w.Newline()
+}
+
 func (d ConstDecls) writeDecl(w *code.Writer) {
 	writeDeclItemSection(w, d, "const")
 }
@@ -324,6 +480,25 @@ func (d MethDecls) writeDecl(w *code.Writer) {
 
 func (d TypeDecls) writeDecl(w *code.Writer) {
 	writeDeclItemSection(w, d, "type")
+
+	var (
+		meths  MethDecls
+		checks VarDecls
+	)
+
+	for _, itm := range d {
+		meths = append(meths, itm.meths()...)
+
+		for _, iface := range itm.Implements {
+			checks = append(checks, VarDecl{ID: Ignore, Type: iface.Type, Value: itm.implementer(w, iface)})
+		}
+	}
+
+	if len(meths) > 0 {
+		meths.writeDecl(w)
+	}
+
+	checks.writeDecl(w)
 }
 
 func (d VarDecls) writeDecl(w *code.Writer) {
@@ -331,13 +506,30 @@ func (d VarDecls) writeDecl(w *code.Writer) {
 }
 
 func (p GenParam) simpleGenParam() bool {
-	return p.Const.simpleConstraint()
+	return p.constraint().simpleConstraints()
 }
 
-func (p GenParam) write(w *code.Writer) {
+func (p GenParam) write(w *code.Writer, last bool) {
 	p.ID.write(w)
-	w.Space()
-	p.Const.writeConstraint(w)
+
+	if cnst := p.constraint(); len(cnst) > 0 {
+		w.Space()
+		cnst.writeConstraints(w)
+	} else if last {
+		w.Fail(fmt.Errorf("type parameter '%s' requires a constraint", p.ID))
+	}
+}
+
+// constraint returns the terms of the constraint of p; it is empty if p shares the constraint of the next parameter.
+func (p GenParam) constraint() GenConsts {
+	switch {
+	case len(p.Union) > 0:
+		return p.Union
+	case p.Const.Tilde || p.Const.Base != nil:
+		return GenConsts{p.Const}
+	}
+
+	return nil
 }
 
 func (p GenParams) simpleGenParams() bool {
@@ -362,14 +554,14 @@ func (p GenParams) write(w *code.Writer) {
 			w.WriteString(", ")
 		}
 
-		itm.write(w)
+		itm.write(w, idx == len(p)-1)
 	}
 
 	w.WriteByte(']')
 }
 
-func genParamsString(p GenParams) string {
-	return writeString(func(w *code.Writer) { p.write(w) })
+func genParamsString(w *code.Writer, p GenParams) string {
+	return w.Capture(func(w *code.Writer) { p.write(w) })
 }
 
 func (c GenConst) simpleConstraint() bool {
@@ -384,6 +576,26 @@ func (c GenConst) writeConstraint(w *code.Writer) {
 	writeType(w, c.Base, "generic contraint requires a base type")
 }
 
+func (c GenConsts) simpleConstraints() bool {
+	for _, itm := range c {
+		if !itm.simpleConstraint() {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (c GenConsts) writeConstraints(w *code.Writer) {
+	for idx, itm := range c {
+		if idx > 0 {
+			w.WriteString(" | ")
+		}
+
+		itm.writeConstraint(w)
+	}
+}
+
 func (p Param) simpleParam() bool {
 	return p.Type == nil || p.Type.simpleType()
 }
@@ -400,6 +612,15 @@ func (p Param) write(w *code.Writer) {
 		}
 	}
 
+	if p.Variadic {
+		if p.Type == nil {
+			w.Fail(errors.New("variadic parameter requires a type"))
+			return
+		}
+
+		w.WriteString("...")
+	}
+
 	writeType(w, p.Type, reqMsg)
 }
 
@@ -425,8 +646,30 @@ func (p Params) write(w *code.Writer, forceParens bool) {
 
 	if forceParens {
 		w.WriteByte('(')
+
+		if len(p) > 0 && !w.FitsLine(1, p.writeLine) {
+			// one parameter per line
+			w.Newline()
+			w.Indent(func(w *code.Writer) {
+				for _, itm := range p {
+					itm.write(w)
+					w.WriteByte(',')
+					w.Newline()
+				}
+			})
+			w.WriteByte(')')
+			return
+		}
 	}
 
+	p.writeLine(w)
+
+	if forceParens {
+		w.WriteByte(')')
+	}
+}
+
+func (p Params) writeLine(w *code.Writer) {
 	for idx, itm := range p {
 		if idx > 0 {
 			w.WriteString(", ")
@@ -434,14 +677,33 @@ func (p Params) write(w *code.Writer, forceParens bool) {
 
 		itm.write(w)
 	}
+}
 
-	if forceParens {
-		w.WriteByte(')')
+func writeIDs(w *code.Writer, ids []ID) {
+	for idx, itm := range ids {
+		if idx > 0 {
+			w.WriteString(", ")
+		}
+
+		itm.write(w)
 	}
 }
 
-func paramsString(p Params, forceParens bool) string {
-	return writeString(func(w *code.Writer) { p.write(w, forceParens) })
+func writeValues(w *code.Writer, vals Exprs, singleLine bool) {
+	if len(vals) == 1 {
+		writeExpr(w, vals[0], singleLine, "value must not be nil")
+		return
+	}
+
+	vals.writeExprs(w, true)
+}
+
+func idsString(w *code.Writer, ids []ID) string {
+	return w.Capture(func(w *code.Writer) { writeIDs(w, ids) })
+}
+
+func paramsString(w *code.Writer, p Params, forceParens bool) string {
+	return w.Capture(func(w *code.Writer) { p.write(w, forceParens) })
 }
 
 func writeDeclItemSection[T declItem](w *code.Writer, items []T, keyword string) {
@@ -479,7 +741,7 @@ func writeDeclItems[T declItem](w *code.Writer, items []T) {
 			rows := make([]code.TableRow, cnt)
 
 			for idx, itm := range items[:cnt] {
-				rows[idx] = itm.declItemRow()
+				rows[idx] = itm.declItemRow(w)
 			}
 
 			w.Table(rows...)
@@ -507,6 +769,29 @@ func countSimpleDeclItems[T declItem](items []T) int {
 	return len(items)
 }
 
+// builtinZeros are the zero values of the predeclared types that have a literal.
+var builtinZeros = map[ID]Expr{
+	Bool.ID:       False,
+	String.ID:     StringExpr(""),
+	Int.ID:        IntExpr(0),
+	Int8.ID:       IntExpr(0),
+	Int16.ID:      IntExpr(0),
+	Int32.ID:      IntExpr(0),
+	Int64.ID:      IntExpr(0),
+	Uint.ID:       IntExpr(0),
+	Uint8.ID:      IntExpr(0),
+	Uint16.ID:     IntExpr(0),
+	Uint32.ID:     IntExpr(0),
+	Uint64.ID:     IntExpr(0),
+	UintPtr.ID:    IntExpr(0),
+	Byte.ID:       IntExpr(0),
+	Rune.ID:       IntExpr(0),
+	Float32.ID:    IntExpr(0),
+	Float64.ID:    IntExpr(0),
+	Complex64.ID:  IntExpr(0),
+	Complex128.ID: IntExpr(0),
+}
+
 var (
 	_ declItem = ConstDecl{}
 	_ declItem = FuncDecl{}
@@ -516,6 +801,7 @@ var (
 	_ TypeSpec = TypeAlias{}
 	_ Decl     = Comment("")
 	_ Decl     = ConstDecls{}
+	_ Decl     = RegionDecl{}
 	_ Decl     = FuncDecls{}
 	_ Decl     = MethDecls{}
 	_ Decl     = TypeDecls{}
@@ -0,0 +1,267 @@
+package golang
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	code "github.com/trwk76/go-code"
+)
+
+type (
+	// Doc is a doc comment written in the Go doc comment syntax (see https://go.dev/doc/comment).
+	// Blocks are separated by blank lines, and followed by the deprecation notice and the directives.
+	Doc struct {
+		Blocks []DocBlock
+		// Deprecated, when not empty, is written as the last paragraph, after "Deprecated: ".
+		Deprecated string
+		Directives []Directive
+	}
+
+	// DocBlock is one of DocText, DocHeading, DocList, DocCode, DocLinkDef, or a Comment written as is.
+	DocBlock interface {
+		writeDocBlock(w *code.Writer)
+	}
+
+	// DocText is a paragraph; it may hold doc links (see DocLink).
+	DocText string
+
+	// DocHeading is a section heading; it must fit on a single line.
+	DocHeading string
+
+	// DocList is a bullet list, or a numbered list if Numbered is set.
+	DocList struct {
+		Numbered bool
+		Items    []string
+	}
+
+	// DocCode is a preformatted code block.
+	DocCode string
+
+	// DocLinkDef defines the target of the doc links written [Text].
+	DocLinkDef struct {
+		Text string
+		URL  string
+	}
+
+	// Directive is a comment meant for tools, such as //go:generate; Name includes the namespace (go:generate).
+	Directive struct {
+		Name string
+		Args string
+	}
+
+	// BlockComment is a free-standing /* */ comment.
+	BlockComment string
+)
+
+// DocLink returns the doc link to s, to be used within a DocText.
+func DocLink(s Symbol) string {
+	switch {
+	case s.Package != nil:
+		return "[" + s.Package.path + "." + string(s.ID) + "]"
+	case s.Path != "":
+		return "[" + s.Path + "." + string(s.ID) + "]"
+	}
+
+	return "[" + string(s.ID) + "]"
+}
+
+// Generate returns the //go:generate directive running command.
+func Generate(command string) Directive {
+	return Directive{Name: "go:generate", Args: command}
+}
+
+// Embed returns the //go:embed directive embedding the files matching patterns.
+func Embed(patterns ...string) Directive {
+	return Directive{Name: "go:embed", Args: strings.Join(patterns, " ")}
+}
+
+func (d Doc) empty() bool {
+	return len(d.Blocks) < 1 && d.Deprecated == "" && len(d.Directives) < 1
+}
+
+func (d Doc) write(w *code.Writer) {
+	blocks := d.Blocks
+
+	if d.Deprecated != "" {
+		blocks = append(blocks[:len(blocks):len(blocks)], DocText("Deprecated: "+d.Deprecated))
+	}
+
+	for idx, itm := range blocks {
+		if idx > 0 {
+			writeDocLine(w, "")
+		}
+
+		if itm == nil {
+			w.Fail(errors.New("doc block must not be nil"))
+			continue
+		}
+
+		itm.writeDocBlock(w)
+	}
+
+	for idx, itm := range d.Directives {
+		if idx == 0 && len(blocks) > 0 {
+			writeDocLine(w, "")
+		}
+
+		itm.write(w)
+		w.Newline()
+	}
+}
+
+func (c Comment) writeDocBlock(w *code.Writer) {
+	c.write(w)
+}
+
+func (t DocText) writeDocBlock(w *code.Writer) {
+	for _, line := range strings.Split(string(t), "\n") {
+		line = strings.TrimSpace(line)
+
+		if line == "" {
+			w.Fail(errors.New("doc paragraph must not hold blank lines"))
+			continue
+		}
+
+		writeDocLine(w, line)
+	}
+}
+
+func (h DocHeading) writeDocBlock(w *code.Writer) {
+	if h == "" || strings.ContainsAny(string(h), "\r\n") {
+		w.Fail(fmt.Errorf("invalid doc heading '%s'", h))
+		return
+	}
+
+	writeDocLine(w, "# "+string(h))
+}
+
+func (l DocList) writeDocBlock(w *code.Writer) {
+	if len(l.Items) < 1 {
+		w.Fail(errors.New("doc list requires items"))
+		return
+	}
+
+	for idx, itm := range l.Items {
+		marker := "  -"
+		if l.Numbered {
+			marker = fmt.Sprintf("%2d.", idx+1)
+		}
+
+		for lidx, line := range strings.Split(itm, "\n") {
+			if lidx > 0 {
+				marker = "   "
+			}
+
+			writeDocLine(w, marker+" "+strings.TrimSpace(line))
+		}
+	}
+}
+
+func (c DocCode) writeDocBlock(w *code.Writer) {
+	for _, line := range strings.Split(strings.TrimRight(string(c), "\n"), "\n") {
+		if strings.TrimSpace(line) == "" {
+			w.WriteString("//")
+		} else {
+			w.WriteString("//\t" + line)
+		}
+
+		w.Newline()
+	}
+}
+
+func (l DocLinkDef) writeDocBlock(w *code.Writer) {
+	if l.Text == "" || strings.ContainsAny(l.Text, "[]\r\n") || l.URL == "" || strings.ContainsAny(l.URL, " \r\n") {
+		w.Fail(fmt.Errorf("invalid doc link definition '[%s]: %s'", l.Text, l.URL))
+		return
+	}
+
+	writeDocLine(w, "["+l.Text+"]: "+l.URL)
+}
+
+func (d Directive) check() error {
+	ns, name, ok := strings.Cut(d.Name, ":")
+	if !ok || !isDirectiveWord(ns) || !isDirectiveWord(name) || strings.ContainsAny(d.Args, "\r\n") {
+		return fmt.Errorf("invalid directive '//%s %s'", d.Name, d.Args)
+	}
+
+	return nil
+}
+
+func (d Directive) write(w *code.Writer) {
+	if err := d.check(); err != nil {
+		w.Fail(err)
+		return
+	}
+
+	w.WriteString("//" + d.Name)
+
+	if d.Args != "" {
+		w.WriteString(" " + d.Args)
+	}
+}
+
+func (d Directive) writeDecl(w *code.Writer) {
+	w.Newline()
+	d.write(w)
+	w.Newline()
+}
+
+func (c BlockComment) writeDecl(w *code.Writer) {
+	if strings.Contains(string(c), "*/") {
+		w.Fail(errors.New("block comment must not contain '*/'"))
+		return
+	}
+
+	w.Newline()
+	w.WriteString("/*" + string(c) + "*/")
+	w.Newline()
+}
+
+func writeDocLine(w *code.Writer, line string) {
+	w.WriteString("//")
+
+	if line != "" {
+		w.WriteString(" " + line)
+	}
+
+	w.Newline()
+}
+
+// writeComment writes the raw comment c followed by the doc comment d.
+func writeComment(w *code.Writer, c Comment, d Doc) {
+	c.write(w)
+
+	if !d.empty() {
+		d.write(w)
+	}
+}
+
+func commentString(w *code.Writer, c Comment, d Doc) string {
+	return w.Capture(func(w *code.Writer) { writeComment(w, c, d) })
+}
+
+func isDirectiveWord(s string) bool {
+	if s == "" {
+		return false
+	}
+
+	for _, r := range s {
+		if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '_') {
+			return false
+		}
+	}
+
+	return true
+}
+
+var (
+	_ DocBlock = Comment("")
+	_ DocBlock = DocText("")
+	_ DocBlock = DocHeading("")
+	_ DocBlock = DocList{}
+	_ DocBlock = DocCode("")
+	_ DocBlock = DocLinkDef{}
+	_ Decl     = Directive{}
+	_ Decl     = BlockComment("")
+)
@@ -2,7 +2,10 @@ package golang
 
 import (
 	"errors"
+	"fmt"
+	"math"
 	"strconv"
+	"strings"
 
 	code "github.com/trwk76/go-code"
 )
@@ -14,12 +17,41 @@ var (
 	True  BoolExpr = BoolExpr(true)
 )
 
+// Operator precedences as defined by the Go specification; operands and primary expressions bind tightest.
+const (
+	precOr = iota + 1
+	precAnd
+	precCmp
+	precAdd
+	precMul
+	precUnary
+	precPrimary
+)
+
+const (
+	Decimal IntFormat = iota
+	Hexadecimal
+	Octal
+	Binary
+)
+
+// bytesPerLine is the maximum number of bytes per line of a BytesExpr table.
+const bytesPerLine = 16
+
 type (
 	Expr interface {
 		simpleExpr() bool
 		writeExpr(w *code.Writer, singleLine bool)
 	}
 
+	// headerKey is the writer value telling whether a statement header is being written.
+	headerKey struct{}
+
+	// precExpr is implemented by expressions that do not bind as tightly as primary expressions.
+	precExpr interface {
+		precedence() int
+	}
+
 	NilExpr    struct{}
 	IotaExpr   struct{}
 	BoolExpr   bool
@@ -29,6 +61,26 @@ type (
 	RuneExpr   rune
 	StringExpr string
 
+	// ExpFloatExpr is a FloatExpr written in scientific notation, as in 1e3 or 2.5e-7.
+	ExpFloatExpr float64
+
+	// RawStringExpr is a string written as a raw literal (`text`), possibly spanning several lines.
+	// The backticks and carriage returns it holds, which raw literals cannot, are written as
+	// interpreted literals concatenated with the raw parts.
+	RawStringExpr string
+
+	// IntFormatExpr is an IntExpr or UintExpr written in Format, with at least Digits digits.
+	IntFormatExpr struct {
+		Value  Expr
+		Format IntFormat
+		Digits int
+	}
+
+	IntFormat uint8
+
+	// BytesExpr is a []byte literal written as a table of hexadecimal bytes, up to 16 per line.
+	BytesExpr []byte
+
 	ParExpr struct {
 		Expr Expr
 	}
@@ -85,7 +137,16 @@ type (
 
 	CallExpr struct {
 		Func Expr
-		Args Exprs
+		// GenArgs explicitly instantiate a generic function, as in f[int](x).
+		GenArgs GenArgs
+		Args    Exprs
+		// Spread passes the last argument, a slice, as the variadic parameter (f(xs...)).
+		Spread bool
+	}
+
+	TypeAssertExpr struct {
+		Value Expr
+		Type  Type
 	}
 
 	IndexExpr struct {
@@ -123,6 +184,10 @@ type (
 		Op Expr
 	}
 
+	RecvExpr struct {
+		Chan Expr
+	}
+
 	AddExpr struct {
 		LHS Expr
 		RHS Expr
@@ -193,6 +258,11 @@ type (
 		RHS Expr
 	}
 
+	BitClearExpr struct {
+		LHS Expr
+		RHS Expr
+	}
+
 	BitXorExpr struct {
 		LHS Expr
 		RHS Expr
@@ -252,6 +322,14 @@ func (e IntExpr) simpleExpr() bool {
 	return true
 }
 
+func (e IntExpr) precedence() int {
+	if e < 0 {
+		return precUnary
+	}
+
+	return precPrimary
+}
+
 func (e IntExpr) writeExpr(w *code.Writer, singleLine bool) {
 	w.WriteString(strconv.FormatInt(int64(e), 10))
 }
@@ -268,8 +346,35 @@ func (e FloatExpr) simpleExpr() bool {
 	return true
 }
 
+func (e FloatExpr) precedence() int {
+	if math.Signbit(float64(e)) {
+		return precUnary
+	}
+
+	return precPrimary
+}
+
 func (e FloatExpr) writeExpr(w *code.Writer, singleLine bool) {
-	w.WriteString(strconv.FormatFloat(float64(e), 'g', -1, 64))
+	str := strconv.FormatFloat(float64(e), 'g', -1, 64)
+
+	// without a fraction or an exponent, the literal would be an integer
+	if !strings.ContainsAny(str, ".eIN") {
+		str += ".0"
+	}
+
+	w.WriteString(exponentLiteral(str))
+}
+
+func (e ExpFloatExpr) simpleExpr() bool {
+	return true
+}
+
+func (e ExpFloatExpr) precedence() int {
+	return FloatExpr(e).precedence()
+}
+
+func (e ExpFloatExpr) writeExpr(w *code.Writer, singleLine bool) {
+	w.WriteString(exponentLiteral(strconv.FormatFloat(float64(e), 'e', -1, 64)))
 }
 
 func (e RuneExpr) simpleExpr() bool {
@@ -288,13 +393,206 @@ func (e StringExpr) writeExpr(w *code.Writer, singleLine bool) {
 	w.WriteString(strconv.Quote(string(e)))
 }
 
+func (e RawStringExpr) simpleExpr() bool {
+	return !strings.Contains(string(e), "\n")
+}
+
+func (e RawStringExpr) precedence() int {
+	if strings.ContainsAny(string(e), "`\r") {
+		return precAdd
+	}
+
+	return precPrimary
+}
+
+func (e RawStringExpr) writeExpr(w *code.Writer, singleLine bool) {
+	if singleLine && !e.simpleExpr() {
+		StringExpr(e).writeExpr(w, singleLine)
+		return
+	}
+
+	str := string(e)
+	sep := ""
+
+	for {
+		idx := strings.IndexAny(str, "`\r")
+		if idx < 0 {
+			break
+		}
+
+		if idx > 0 {
+			w.WriteString(sep)
+			w.WriteVerbatim("`" + str[:idx] + "`")
+			sep = " + "
+		}
+
+		end := idx + 1
+		for end < len(str) && (str[end] == '`' || str[end] == '\r') {
+			end++
+		}
+
+		w.WriteString(sep + strconv.Quote(str[idx:end]))
+		str = str[end:]
+		sep = " + "
+	}
+
+	if str != "" || sep == "" {
+		w.WriteString(sep)
+		w.WriteVerbatim("`" + str + "`")
+	}
+}
+
+// Format returns e written in f, with at least digits digits.
+func (e IntExpr) Format(f IntFormat, digits int) IntFormatExpr {
+	return IntFormatExpr{Value: e, Format: f, Digits: digits}
+}
+
+// Format returns e written in f, with at least digits digits.
+func (e UintExpr) Format(f IntFormat, digits int) IntFormatExpr {
+	return IntFormatExpr{Value: e, Format: f, Digits: digits}
+}
+
+func (e IntFormatExpr) simpleExpr() bool {
+	return true
+}
+
+func (e IntFormatExpr) precedence() int {
+	if val, ok := e.Value.(IntExpr); ok && val < 0 {
+		return precUnary
+	}
+
+	return precPrimary
+}
+
+func (e IntFormatExpr) writeExpr(w *code.Writer, singleLine bool) {
+	var val uint64
+
+	switch v := e.Value.(type) {
+	case IntExpr:
+		if v < 0 {
+			w.WriteByte('-')
+			val = uint64(-v)
+		} else {
+			val = uint64(v)
+		}
+	case UintExpr:
+		val = uint64(v)
+	default:
+		w.Fail(fmt.Errorf("formatted integer requires an IntExpr or UintExpr; %T found", e.Value))
+		return
+	}
+
+	prefix, base := "", 10
+
+	switch e.Format {
+	case Decimal:
+	case Hexadecimal:
+		prefix, base = "0x", 16
+	case Octal:
+		prefix, base = "0o", 8
+	case Binary:
+		prefix, base = "0b", 2
+	default:
+		w.Fail(fmt.Errorf("invalid integer format %d", e.Format))
+		return
+	}
+
+	digits := strconv.FormatUint(val, base)
+	if pad := e.Digits - len(digits); pad > 0 {
+		digits = strings.Repeat("0", pad) + digits
+	}
+
+	if e.Format == Decimal && len(digits) > 1 && digits[0] == '0' {
+		w.Fail(errors.New("decimal integers cannot be padded with zeros"))
+		return
+	}
+
+	w.WriteString(prefix + digits)
+}
+
+func (e BytesExpr) simpleExpr() bool {
+	return len(e) <= bytesPerLine
+}
+
+func (e BytesExpr) writeExpr(w *code.Writer, singleLine bool) {
+	if singleLine || e.simpleExpr() && w.FitsLine(0, e.writeLine) {
+		e.writeLine(w)
+		return
+	}
+
+	w.WriteString("[]byte{")
+	w.Newline()
+	w.Indent(func(w *code.Writer) {
+		count := 0
+
+		for _, itm := range e {
+			lit := byteLiteral(itm) + ","
+
+			if count > 0 {
+				// wrap when the line is full or would exceed the max width
+				if count == bytesPerLine || !w.FitsLine(0, func(w *code.Writer) { w.WriteString(" " + lit) }) {
+					w.Newline()
+					count = 0
+				} else {
+					w.Space()
+				}
+			}
+
+			w.WriteString(lit)
+			count++
+		}
+
+		w.Newline()
+	})
+	w.WriteByte('}')
+}
+
+func (e BytesExpr) writeLine(w *code.Writer) {
+	w.WriteString("[]byte{")
+
+	for idx, itm := range e {
+		if idx > 0 {
+			w.WriteString(", ")
+		}
+
+		w.WriteString(byteLiteral(itm))
+	}
+
+	w.WriteByte('}')
+}
+
+// exponentLiteral drops the plus sign and the leading zeros strconv writes in the exponent of str (1e+06 is 1e6).
+func exponentLiteral(str string) string {
+	mant, exp, ok := strings.Cut(str, "e")
+	if !ok {
+		return str
+	}
+
+	sign := ""
+	if exp[0] == '-' || exp[0] == '+' {
+		sign, exp = strings.TrimPrefix(exp[:1], "+"), exp[1:]
+	}
+
+	if exp = strings.TrimLeft(exp, "0"); exp == "" {
+		exp = "0"
+	}
+
+	return mant + "e" + sign + exp
+}
+
+func byteLiteral(b byte) string {
+	return fmt.Sprintf("0x%02x", b)
+}
+
 func (e ParExpr) simpleExpr() bool {
 	return e.Expr == nil || e.Expr.simpleExpr()
 }
 
 func (e ParExpr) writeExpr(w *code.Writer, singleLine bool) {
 	w.WriteByte('(')
-	writeExpr(w, e.Expr, singleLine, "parenthesis expression requires an inner expression")
+	writeNested(w, func(w *code.Writer) {
+		writeExpr(w, e.Expr, singleLine, "parenthesis expression requires an inner expression")
+	})
 	w.WriteByte(')')
 }
 
@@ -307,7 +605,9 @@ func (e CastExpr) writeExpr(w *code.Writer, singleLine bool) {
 	w.WriteByte('(')
 	writeType(w, e.Type, "cast expression requires a target type")
 	w.WriteString(")(")
-	writeExpr(w, e.Value, singleLine, "cast expression requires a value expression")
+	writeNested(w, func(w *code.Writer) {
+		writeExpr(w, e.Value, singleLine, "cast expression requires a value expression")
+	})
 	w.WriteByte(')')
 }
 
@@ -317,6 +617,10 @@ func (e SliceExpr) simpleExpr() bool {
 }
 
 func (e SliceExpr) writeExpr(w *code.Writer, singleLine bool) {
+	if headerComposite(w, e.Type, e, singleLine) {
+		return
+	}
+
 	writeType(w, e.Type, "")
 	w.WriteByte('{')
 	e.Items.writeExprs(w, singleLine)
@@ -342,11 +646,15 @@ func (e MapExpr) simpleExpr() bool {
 }
 
 func (e MapExpr) writeExpr(w *code.Writer, singleLine bool) {
+	if headerComposite(w, e.Type, e, singleLine) {
+		return
+	}
+
 	writeType(w, e.Type, "")
 	w.WriteByte('{')
 
 	if len(e.Entries) > 0 {
-		if singleLine {
+		if singleLine || e.fitsLine(w) {
 			for idx, itm := range e.Entries {
 				if idx > 0 {
 					w.WriteString(", ")
@@ -373,6 +681,22 @@ func (e MapExpr) writeExpr(w *code.Writer, singleLine bool) {
 	w.WriteByte('}')
 }
 
+// fitsLine returns whether the entries fit on a single line; without a max line width, they are
+// always written one per line.
+func (e MapExpr) fitsLine(w *code.Writer) bool {
+	if w.MaxWidth() < 1 {
+		return false
+	}
+
+	for _, itm := range e.Entries {
+		if !(MapExpr{Entries: []MapEntry{itm}}).simpleExpr() {
+			return false
+		}
+	}
+
+	return w.FitsLine(1, func(w *code.Writer) { MapExpr{Entries: e.Entries}.writeExpr(w, true) })
+}
+
 func (e StructExpr) simpleExpr() bool {
 	if len(e.Fields) > 1 {
 		return false
@@ -388,11 +712,15 @@ func (e StructExpr) simpleExpr() bool {
 }
 
 func (e StructExpr) writeExpr(w *code.Writer, singleLine bool) {
+	if headerComposite(w, e.Type, e, singleLine) {
+		return
+	}
+
 	writeType(w, e.Type, "")
 	w.WriteByte('{')
 
 	if len(e.Fields) > 0 {
-		if singleLine {
+		if singleLine || e.fitsLine(w) {
 			for idx, itm := range e.Fields {
 				if idx > 0 {
 					w.WriteString(", ")
@@ -419,6 +747,22 @@ func (e StructExpr) writeExpr(w *code.Writer, singleLine bool) {
 	w.WriteByte('}')
 }
 
+// fitsLine returns whether the fields fit on a single line; without a max line width, they are
+// always written one per line.
+func (e StructExpr) fitsLine(w *code.Writer) bool {
+	if w.MaxWidth() < 1 {
+		return false
+	}
+
+	for _, itm := range e.Fields {
+		if !(StructExpr{Fields: []StructExprField{itm}}).simpleExpr() {
+			return false
+		}
+	}
+
+	return w.FitsLine(1, func(w *code.Writer) { StructExpr{Fields: e.Fields}.writeExpr(w, true) })
+}
+
 func (e NewExpr) simpleExpr() bool {
 	return (e.Type == nil || e.Type.simpleType())
 }
@@ -438,7 +782,7 @@ func (e FuncExpr) writeExpr(w *code.Writer, singleLine bool) {
 	e.Params.write(w, true)
 	e.Return.write(w, false)
 	w.Space()
-	e.Body.writeStmt(w, singleLine)
+	writeNested(w, func(w *code.Writer) { e.Body.writeStmt(w, singleLine) })
 }
 
 func (e MakeExpr) simpleExpr() bool {
@@ -454,11 +798,12 @@ func (e MakeExpr) simpleExpr() bool {
 func (e MakeExpr) writeExpr(w *code.Writer, singleLine bool) {
 	w.WriteString("make(")
 	writeType(w, e.Type, "make function requires a type")
-
-	for _, itm := range e.Sizes {
-		w.WriteString(", ")
-		writeExpr(w, itm, singleLine, "make length argument must not be nil")
-	}
+	writeNested(w, func(w *code.Writer) {
+		for _, itm := range e.Sizes {
+			w.WriteString(", ")
+			writeExpr(w, itm, singleLine, "make length argument must not be nil")
+		}
+	})
 
 	w.WriteByte(')')
 }
@@ -468,20 +813,45 @@ func (e MemberExpr) simpleExpr() bool {
 }
 
 func (e MemberExpr) writeExpr(w *code.Writer, singleLine bool) {
-	writeExpr(w, e.Value, singleLine, "member expression requires a value expression")
+	writeOperand(w, e.Value, precPrimary, singleLine, "member expression requires a value expression")
 	w.WriteByte('.')
 	e.ID.write(w)
 }
 
 func (e CallExpr) simpleExpr() bool {
 	return (e.Func == nil || e.Func.simpleExpr()) &&
-	    e.Args.simpleExprs()
+		e.GenArgs.simpleGenArgs() &&
+		e.Args.simpleExprs()
 }
 
 func (e CallExpr) writeExpr(w *code.Writer, singleLine bool) {
-	writeExpr(w, e.Func, singleLine, "call expression requires a function expression")
+	writeOperand(w, e.Func, precPrimary, singleLine, "call expression requires a function expression")
+	e.GenArgs.write(w)
 	w.WriteByte('(')
-	e.Args.writeExprs(w, singleLine)
+
+	suffix := ""
+	if e.Spread {
+		if len(e.Args) < 1 {
+			w.Fail(errors.New("spread call requires arguments"))
+			return
+		}
+
+		suffix = "..."
+	}
+
+	writeNested(w, func(w *code.Writer) { e.Args.writeList(w, singleLine, suffix) })
+	w.WriteByte(')')
+}
+
+func (e TypeAssertExpr) simpleExpr() bool {
+	return (e.Value == nil || e.Value.simpleExpr()) &&
+		(e.Type == nil || e.Type.simpleType())
+}
+
+func (e TypeAssertExpr) writeExpr(w *code.Writer, singleLine bool) {
+	writeOperand(w, e.Value, precPrimary, singleLine, "type assertion requires a value expression")
+	w.WriteString(".(")
+	writeType(w, e.Type, "type assertion requires a type")
 	w.WriteByte(')')
 }
 
@@ -491,9 +861,11 @@ func (e IndexExpr) simpleExpr() bool {
 }
 
 func (e IndexExpr) writeExpr(w *code.Writer, singleLine bool) {
-	writeExpr(w, e.Slice, singleLine, "index expression requires a slice expression")
+	writeOperand(w, e.Slice, precPrimary, singleLine, "index expression requires a slice expression")
 	w.WriteByte('[')
-	writeExpr(w, e.Index, singleLine, "index expression requires an index expression")
+	writeNested(w, func(w *code.Writer) {
+		writeExpr(w, e.Index, singleLine, "index expression requires an index expression")
+	})
 	w.WriteByte(']')
 }
 
@@ -504,11 +876,13 @@ func (e RangeExpr) simpleExpr() bool {
 }
 
 func (e RangeExpr) writeExpr(w *code.Writer, singleLine bool) {
-	writeExpr(w, e.Slice, singleLine, "index expression requires a slice expression")
+	writeOperand(w, e.Slice, precPrimary, singleLine, "range expression requires a slice expression")
 	w.WriteByte('[')
-	writeExpr(w, e.Min, singleLine, "")
-	w.WriteByte(':')
-	writeExpr(w, e.Max, singleLine, "")
+	writeNested(w, func(w *code.Writer) {
+		writeExpr(w, e.Min, singleLine, "")
+		w.WriteByte(':')
+		writeExpr(w, e.Max, singleLine, "")
+	})
 	w.WriteByte(']')
 }
 
@@ -516,54 +890,91 @@ func (e IdentExpr) simpleExpr() bool {
 	return (e.Op == nil || e.Op.simpleExpr())
 }
 
+func (e IdentExpr) precedence() int {
+	return precUnary
+}
+
 func (e IdentExpr) writeExpr(w *code.Writer, singleLine bool) {
 	w.WriteByte('+')
-	writeExpr(w, e.Op, singleLine, "identity expression requires an operand expression")
+	writeUnaryOperand(w, '+', e.Op, singleLine, "identity expression requires an operand expression")
 }
 
 func (e NegateExpr) simpleExpr() bool {
 	return (e.Op == nil || e.Op.simpleExpr())
 }
 
+func (e NegateExpr) precedence() int {
+	return precUnary
+}
+
 func (e NegateExpr) writeExpr(w *code.Writer, singleLine bool) {
 	w.WriteByte('-')
-	writeExpr(w, e.Op, singleLine, "negation expression requires an operand expression")
+	writeUnaryOperand(w, '-', e.Op, singleLine, "negation expression requires an operand expression")
 }
 
 func (e NotExpr) simpleExpr() bool {
 	return (e.Op == nil || e.Op.simpleExpr())
 }
 
+func (e NotExpr) precedence() int {
+	return precUnary
+}
+
 func (e NotExpr) writeExpr(w *code.Writer, singleLine bool) {
 	w.WriteByte('!')
-	writeExpr(w, e.Op, singleLine, "not expression requires an operand expression")
+	writeUnaryOperand(w, '!', e.Op, singleLine, "not expression requires an operand expression")
 }
 
 func (e ComplementExpr) simpleExpr() bool {
 	return (e.Op == nil || e.Op.simpleExpr())
 }
 
+func (e ComplementExpr) precedence() int {
+	return precUnary
+}
+
 func (e ComplementExpr) writeExpr(w *code.Writer, singleLine bool) {
 	w.WriteByte('^')
-	writeExpr(w, e.Op, singleLine, "complement expression requires an operand expression")
+	writeUnaryOperand(w, '^', e.Op, singleLine, "complement expression requires an operand expression")
 }
 
 func (e AddrOfExpr) simpleExpr() bool {
 	return (e.Op == nil || e.Op.simpleExpr())
 }
 
+func (e AddrOfExpr) precedence() int {
+	return precUnary
+}
+
 func (e AddrOfExpr) writeExpr(w *code.Writer, singleLine bool) {
 	w.WriteByte('&')
-	writeExpr(w, e.Op, singleLine, "address-of expression requires an operand expression")
+	writeUnaryOperand(w, '&', e.Op, singleLine, "address-of expression requires an operand expression")
 }
 
 func (e DerefExpr) simpleExpr() bool {
 	return (e.Op == nil || e.Op.simpleExpr())
 }
 
+func (e DerefExpr) precedence() int {
+	return precUnary
+}
+
 func (e DerefExpr) writeExpr(w *code.Writer, singleLine bool) {
 	w.WriteByte('*')
-	writeExpr(w, e.Op, singleLine, "dereference expression requires an operand expression")
+	writeUnaryOperand(w, '*', e.Op, singleLine, "dereference expression requires an operand expression")
+}
+
+func (e RecvExpr) simpleExpr() bool {
+	return (e.Chan == nil || e.Chan.simpleExpr())
+}
+
+func (e RecvExpr) precedence() int {
+	return precUnary
+}
+
+func (e RecvExpr) writeExpr(w *code.Writer, singleLine bool) {
+	w.WriteString("<-")
+	writeOperand(w, e.Chan, precUnary, singleLine, "receive expression requires a channel expression")
 }
 
 func (e AddExpr) simpleExpr() bool {
@@ -571,10 +982,14 @@ func (e AddExpr) simpleExpr() bool {
 		(e.RHS == nil || e.RHS.simpleExpr())
 }
 
+func (e AddExpr) precedence() int {
+	return precAdd
+}
+
 func (e AddExpr) writeExpr(w *code.Writer, singleLine bool) {
-	writeExpr(w, e.LHS, singleLine, "add expression requires a left-hand operand expression")
+	writeOperand(w, e.LHS, precAdd, singleLine, "add expression requires a left-hand operand expression")
 	w.WriteString(" + ")
-	writeExpr(w, e.RHS, singleLine, "add expression requires a right-hand operand expression")
+	writeOperand(w, e.RHS, precAdd+1, singleLine, "add expression requires a right-hand operand expression")
 }
 
 func (e SubtractExpr) simpleExpr() bool {
@@ -582,10 +997,14 @@ func (e SubtractExpr) simpleExpr() bool {
 		(e.RHS == nil || e.RHS.simpleExpr())
 }
 
+func (e SubtractExpr) precedence() int {
+	return precAdd
+}
+
 func (e SubtractExpr) writeExpr(w *code.Writer, singleLine bool) {
-	writeExpr(w, e.LHS, singleLine, "subtract expression requires a left-hand operand expression")
+	writeOperand(w, e.LHS, precAdd, singleLine, "subtract expression requires a left-hand operand expression")
 	w.WriteString(" - ")
-	writeExpr(w, e.RHS, singleLine, "subtract expression requires a right-hand operand expression")
+	writeOperand(w, e.RHS, precAdd+1, singleLine, "subtract expression requires a right-hand operand expression")
 }
 
 func (e MultiplyExpr) simpleExpr() bool {
@@ -593,10 +1012,14 @@ func (e MultiplyExpr) simpleExpr() bool {
 		(e.RHS == nil || e.RHS.simpleExpr())
 }
 
+func (e MultiplyExpr) precedence() int {
+	return precMul
+}
+
 func (e MultiplyExpr) writeExpr(w *code.Writer, singleLine bool) {
-	writeExpr(w, e.LHS, singleLine, "multiply expression requires a left-hand operand expression")
+	writeOperand(w, e.LHS, precMul, singleLine, "multiply expression requires a left-hand operand expression")
 	w.WriteString(" * ")
-	writeExpr(w, e.RHS, singleLine, "multiply expression requires a right-hand operand expression")
+	writeOperand(w, e.RHS, precMul+1, singleLine, "multiply expression requires a right-hand operand expression")
 }
 
 func (e DivideExpr) simpleExpr() bool {
@@ -604,10 +1027,14 @@ func (e DivideExpr) simpleExpr() bool {
 		(e.RHS == nil || e.RHS.simpleExpr())
 }
 
+func (e DivideExpr) precedence() int {
+	return precMul
+}
+
 func (e DivideExpr) writeExpr(w *code.Writer, singleLine bool) {
-	writeExpr(w, e.LHS, singleLine, "divide expression requires a left-hand operand expression")
+	writeOperand(w, e.LHS, precMul, singleLine, "divide expression requires a left-hand operand expression")
 	w.WriteString(" / ")
-	writeExpr(w, e.RHS, singleLine, "divide expression requires a right-hand operand expression")
+	writeOperand(w, e.RHS, precMul+1, singleLine, "divide expression requires a right-hand operand expression")
 }
 
 func (e ModulusExpr) simpleExpr() bool {
@@ -615,10 +1042,14 @@ func (e ModulusExpr) simpleExpr() bool {
 		(e.RHS == nil || e.RHS.simpleExpr())
 }
 
+func (e ModulusExpr) precedence() int {
+	return precMul
+}
+
 func (e ModulusExpr) writeExpr(w *code.Writer, singleLine bool) {
-	writeExpr(w, e.LHS, singleLine, "modulus expression requires a left-hand operand expression")
+	writeOperand(w, e.LHS, precMul, singleLine, "modulus expression requires a left-hand operand expression")
 	w.WriteString(" % ")
-	writeExpr(w, e.RHS, singleLine, "modulus expression requires a right-hand operand expression")
+	writeOperand(w, e.RHS, precMul+1, singleLine, "modulus expression requires a right-hand operand expression")
 }
 
 func (e ShiftLeftExpr) simpleExpr() bool {
@@ -626,10 +1057,14 @@ func (e ShiftLeftExpr) simpleExpr() bool {
 		(e.RHS == nil || e.RHS.simpleExpr())
 }
 
+func (e ShiftLeftExpr) precedence() int {
+	return precMul
+}
+
 func (e ShiftLeftExpr) writeExpr(w *code.Writer, singleLine bool) {
-	writeExpr(w, e.LHS, singleLine, "shift-left expression requires a left-hand operand expression")
+	writeOperand(w, e.LHS, precMul, singleLine, "shift-left expression requires a left-hand operand expression")
 	w.WriteString(" << ")
-	writeExpr(w, e.RHS, singleLine, "shift-left expression requires a right-hand operand expression")
+	writeOperand(w, e.RHS, precMul+1, singleLine, "shift-left expression requires a right-hand operand expression")
 }
 
 func (e ShiftRightExpr) simpleExpr() bool {
@@ -637,10 +1072,14 @@ func (e ShiftRightExpr) simpleExpr() bool {
 		(e.RHS == nil || e.RHS.simpleExpr())
 }
 
+func (e ShiftRightExpr) precedence() int {
+	return precMul
+}
+
 func (e ShiftRightExpr) writeExpr(w *code.Writer, singleLine bool) {
-	writeExpr(w, e.LHS, singleLine, "shift-right expression requires a left-hand operand expression")
+	writeOperand(w, e.LHS, precMul, singleLine, "shift-right expression requires a left-hand operand expression")
 	w.WriteString(" >> ")
-	writeExpr(w, e.RHS, singleLine, "shift-right expression requires a right-hand operand expression")
+	writeOperand(w, e.RHS, precMul+1, singleLine, "shift-right expression requires a right-hand operand expression")
 }
 
 func (e EqualExpr) simpleExpr() bool {
@@ -648,10 +1087,14 @@ func (e EqualExpr) simpleExpr() bool {
 		(e.RHS == nil || e.RHS.simpleExpr())
 }
 
+func (e EqualExpr) precedence() int {
+	return precCmp
+}
+
 func (e EqualExpr) writeExpr(w *code.Writer, singleLine bool) {
-	writeExpr(w, e.LHS, singleLine, "equal expression requires a left-hand operand expression")
+	writeOperand(w, e.LHS, precCmp, singleLine, "equal expression requires a left-hand operand expression")
 	w.WriteString(" == ")
-	writeExpr(w, e.RHS, singleLine, "equal expression requires a right-hand operand expression")
+	writeOperand(w, e.RHS, precCmp+1, singleLine, "equal expression requires a right-hand operand expression")
 }
 
 func (e NotEqualExpr) simpleExpr() bool {
@@ -659,10 +1102,14 @@ func (e NotEqualExpr) simpleExpr() bool {
 		(e.RHS == nil || e.RHS.simpleExpr())
 }
 
+func (e NotEqualExpr) precedence() int {
+	return precCmp
+}
+
 func (e NotEqualExpr) writeExpr(w *code.Writer, singleLine bool) {
-	writeExpr(w, e.LHS, singleLine, "not-equal expression requires a left-hand operand expression")
+	writeOperand(w, e.LHS, precCmp, singleLine, "not-equal expression requires a left-hand operand expression")
 	w.WriteString(" != ")
-	writeExpr(w, e.RHS, singleLine, "not-equal expression requires a right-hand operand expression")
+	writeOperand(w, e.RHS, precCmp+1, singleLine, "not-equal expression requires a right-hand operand expression")
 }
 
 func (e LessThanExpr) simpleExpr() bool {
@@ -670,10 +1117,14 @@ func (e LessThanExpr) simpleExpr() bool {
 		(e.RHS == nil || e.RHS.simpleExpr())
 }
 
+func (e LessThanExpr) precedence() int {
+	return precCmp
+}
+
 func (e LessThanExpr) writeExpr(w *code.Writer, singleLine bool) {
-	writeExpr(w, e.LHS, singleLine, "less-than expression requires a left-hand operand expression")
+	writeOperand(w, e.LHS, precCmp, singleLine, "less-than expression requires a left-hand operand expression")
 	w.WriteString(" < ")
-	writeExpr(w, e.RHS, singleLine, "less-than expression requires a right-hand operand expression")
+	writeOperand(w, e.RHS, precCmp+1, singleLine, "less-than expression requires a right-hand operand expression")
 }
 
 func (e LessOrEqualExpr) simpleExpr() bool {
@@ -681,10 +1132,14 @@ func (e LessOrEqualExpr) simpleExpr() bool {
 		(e.RHS == nil || e.RHS.simpleExpr())
 }
 
+func (e LessOrEqualExpr) precedence() int {
+	return precCmp
+}
+
 func (e LessOrEqualExpr) writeExpr(w *code.Writer, singleLine bool) {
-	writeExpr(w, e.LHS, singleLine, "less-or-equal expression requires a left-hand operand expression")
+	writeOperand(w, e.LHS, precCmp, singleLine, "less-or-equal expression requires a left-hand operand expression")
 	w.WriteString(" <= ")
-	writeExpr(w, e.RHS, singleLine, "less-or-equal expression requires a right-hand operand expression")
+	writeOperand(w, e.RHS, precCmp+1, singleLine, "less-or-equal expression requires a right-hand operand expression")
 }
 
 func (e MoreThanExpr) simpleExpr() bool {
@@ -692,10 +1147,14 @@ func (e MoreThanExpr) simpleExpr() bool {
 		(e.RHS == nil || e.RHS.simpleExpr())
 }
 
+func (e MoreThanExpr) precedence() int {
+	return precCmp
+}
+
 func (e MoreThanExpr) writeExpr(w *code.Writer, singleLine bool) {
-	writeExpr(w, e.LHS, singleLine, "less-than expression requires a left-hand operand expression")
+	writeOperand(w, e.LHS, precCmp, singleLine, "more-than expression requires a left-hand operand expression")
 	w.WriteString(" > ")
-	writeExpr(w, e.RHS, singleLine, "less-than expression requires a right-hand operand expression")
+	writeOperand(w, e.RHS, precCmp+1, singleLine, "more-than expression requires a right-hand operand expression")
 }
 
 func (e MoreOrEqualExpr) simpleExpr() bool {
@@ -703,10 +1162,14 @@ func (e MoreOrEqualExpr) simpleExpr() bool {
 		(e.RHS == nil || e.RHS.simpleExpr())
 }
 
+func (e MoreOrEqualExpr) precedence() int {
+	return precCmp
+}
+
 func (e MoreOrEqualExpr) writeExpr(w *code.Writer, singleLine bool) {
-	writeExpr(w, e.LHS, singleLine, "less-or-equal expression requires a left-hand operand expression")
+	writeOperand(w, e.LHS, precCmp, singleLine, "more-or-equal expression requires a left-hand operand expression")
 	w.WriteString(" >= ")
-	writeExpr(w, e.RHS, singleLine, "less-or-equal expression requires a right-hand operand expression")
+	writeOperand(w, e.RHS, precCmp+1, singleLine, "more-or-equal expression requires a right-hand operand expression")
 }
 
 func (e BitAndExpr) simpleExpr() bool {
@@ -714,10 +1177,29 @@ func (e BitAndExpr) simpleExpr() bool {
 		(e.RHS == nil || e.RHS.simpleExpr())
 }
 
+func (e BitAndExpr) precedence() int {
+	return precMul
+}
+
 func (e BitAndExpr) writeExpr(w *code.Writer, singleLine bool) {
-	writeExpr(w, e.LHS, singleLine, "bit-and expression requires a left-hand operand expression")
+	writeOperand(w, e.LHS, precMul, singleLine, "bit-and expression requires a left-hand operand expression")
 	w.WriteString(" & ")
-	writeExpr(w, e.RHS, singleLine, "bit-and expression requires a right-hand operand expression")
+	writeOperand(w, e.RHS, precMul+1, singleLine, "bit-and expression requires a right-hand operand expression")
+}
+
+func (e BitClearExpr) simpleExpr() bool {
+	return (e.LHS == nil || e.LHS.simpleExpr()) &&
+		(e.RHS == nil || e.RHS.simpleExpr())
+}
+
+func (e BitClearExpr) precedence() int {
+	return precMul
+}
+
+func (e BitClearExpr) writeExpr(w *code.Writer, singleLine bool) {
+	writeOperand(w, e.LHS, precMul, singleLine, "bit-clear expression requires a left-hand operand expression")
+	w.WriteString(" &^ ")
+	writeOperand(w, e.RHS, precMul+1, singleLine, "bit-clear expression requires a right-hand operand expression")
 }
 
 func (e BitXorExpr) simpleExpr() bool {
@@ -725,10 +1207,14 @@ func (e BitXorExpr) simpleExpr() bool {
 		(e.RHS == nil || e.RHS.simpleExpr())
 }
 
+func (e BitXorExpr) precedence() int {
+	return precAdd
+}
+
 func (e BitXorExpr) writeExpr(w *code.Writer, singleLine bool) {
-	writeExpr(w, e.LHS, singleLine, "bit-xor expression requires a left-hand operand expression")
+	writeOperand(w, e.LHS, precAdd, singleLine, "bit-xor expression requires a left-hand operand expression")
 	w.WriteString(" ^ ")
-	writeExpr(w, e.RHS, singleLine, "bit-xor expression requires a right-hand operand expression")
+	writeOperand(w, e.RHS, precAdd+1, singleLine, "bit-xor expression requires a right-hand operand expression")
 }
 
 func (e BitOrExpr) simpleExpr() bool {
@@ -736,10 +1222,14 @@ func (e BitOrExpr) simpleExpr() bool {
 		(e.RHS == nil || e.RHS.simpleExpr())
 }
 
+func (e BitOrExpr) precedence() int {
+	return precAdd
+}
+
 func (e BitOrExpr) writeExpr(w *code.Writer, singleLine bool) {
-	writeExpr(w, e.LHS, singleLine, "bit-or expression requires a left-hand operand expression")
+	writeOperand(w, e.LHS, precAdd, singleLine, "bit-or expression requires a left-hand operand expression")
 	w.WriteString(" | ")
-	writeExpr(w, e.RHS, singleLine, "bit-or expression requires a right-hand operand expression")
+	writeOperand(w, e.RHS, precAdd+1, singleLine, "bit-or expression requires a right-hand operand expression")
 }
 
 func (e LogAndExpr) simpleExpr() bool {
@@ -747,10 +1237,14 @@ func (e LogAndExpr) simpleExpr() bool {
 		(e.RHS == nil || e.RHS.simpleExpr())
 }
 
+func (e LogAndExpr) precedence() int {
+	return precAnd
+}
+
 func (e LogAndExpr) writeExpr(w *code.Writer, singleLine bool) {
-	writeExpr(w, e.LHS, singleLine, "log-and expression requires a left-hand operand expression")
+	writeOperand(w, e.LHS, precAnd, singleLine, "log-and expression requires a left-hand operand expression")
 	w.WriteString(" && ")
-	writeExpr(w, e.RHS, singleLine, "log-and expression requires a right-hand operand expression")
+	writeOperand(w, e.RHS, precAnd+1, singleLine, "log-and expression requires a right-hand operand expression")
 }
 
 func (e LogOrExpr) simpleExpr() bool {
@@ -758,16 +1252,96 @@ func (e LogOrExpr) simpleExpr() bool {
 		(e.RHS == nil || e.RHS.simpleExpr())
 }
 
+func (e LogOrExpr) precedence() int {
+	return precOr
+}
+
 func (e LogOrExpr) writeExpr(w *code.Writer, singleLine bool) {
-	writeExpr(w, e.LHS, singleLine, "log-or expression requires a left-hand operand expression")
+	writeOperand(w, e.LHS, precOr, singleLine, "log-or expression requires a left-hand operand expression")
 	w.WriteString(" || ")
-	writeExpr(w, e.RHS, singleLine, "log-or expression requires a right-hand operand expression")
+	writeOperand(w, e.RHS, precOr+1, singleLine, "log-or expression requires a right-hand operand expression")
+}
+
+// writeOperand writes e as the operand of an operator of precedence prec and adds parentheses
+// when e binds less tightly than prec.
+func writeOperand(w *code.Writer, e Expr, prec int, singleLine bool, reqMessage string) {
+	if e != nil && exprPrec(e) < prec {
+		e = ParExpr{Expr: e}
+	}
+
+	writeExpr(w, e, singleLine, reqMessage)
+}
+
+// writeUnaryOperand writes the operand of the unary operator op; on top of precedence, it adds
+// parentheses when the operand starts with the same sign (- -x would otherwise print --x).
+func writeUnaryOperand(w *code.Writer, op byte, e Expr, singleLine bool, reqMessage string) {
+	if e != nil && (op == '-' || op == '+') && exprPrec(e) == precUnary && unaryOp(e) == op {
+		e = ParExpr{Expr: e}
+	}
+
+	writeOperand(w, e, precUnary, singleLine, reqMessage)
+}
+
+// writeHeader writes the header of an if, for or switch statement with f, where the brace of a
+// composite literal of a named type would be read as the start of the block.
+func writeHeader(w *code.Writer, f code.WriteFunc) {
+	setHeader(w, true, f)
+}
+
+// writeNested writes with f the content of parentheses, brackets or braces, which is not part of
+// an enclosing statement header.
+func writeNested(w *code.Writer, f code.WriteFunc) {
+	setHeader(w, false, f)
+}
+
+func setHeader(w *code.Writer, header bool, f code.WriteFunc) {
+	prev := w.Value(headerKey{})
+	w.SetValue(headerKey{}, header)
+	f(w)
+	w.SetValue(headerKey{}, prev)
+}
+
+// headerComposite writes the composite literal e of type t when it is part of a statement header,
+// and returns whether it did; it is then parenthesized if t is a type name.
+func headerComposite(w *code.Writer, t Type, e Expr, singleLine bool) bool {
+	if header, _ := w.Value(headerKey{}).(bool); !header {
+		return false
+	}
+
+	if _, ok := t.(Symbol); ok {
+		ParExpr{Expr: e}.writeExpr(w, singleLine)
+	} else {
+		writeNested(w, func(w *code.Writer) { e.writeExpr(w, singleLine) })
+	}
+
+	return true
+}
+
+func exprPrec(e Expr) int {
+	if p, ok := e.(precExpr); ok {
+		return p.precedence()
+	}
+
+	return precPrimary
+}
+
+func unaryOp(e Expr) byte {
+	switch e := e.(type) {
+	case Value:
+		return unaryOp(e.expr)
+	case IdentExpr:
+		return '+'
+	case NegateExpr, IntExpr, IntFormatExpr, FloatExpr, ExpFloatExpr:
+		return '-'
+	}
+
+	return 0
 }
 
 func writeExpr(w *code.Writer, e Expr, singleLine bool, reqMessage string) {
 	if e == nil {
 		if reqMessage != "" {
-			panic(errors.New(reqMessage))
+			w.Fail(errors.New(reqMessage))
 		}
 
 		return
@@ -776,8 +1350,12 @@ func writeExpr(w *code.Writer, e Expr, singleLine bool, reqMessage string) {
 	e.writeExpr(w, singleLine)
 }
 
-func exprString(e Expr, reqMessage string) string {
-	return writeString(func(w *code.Writer) { writeExpr(w, e, true, reqMessage) })
+func exprString(w *code.Writer, e Expr, reqMessage string) string {
+	return w.Capture(func(w *code.Writer) { writeExpr(w, e, true, reqMessage) })
+}
+
+func exprsString(w *code.Writer, e Exprs) string {
+	return w.Capture(func(w *code.Writer) { e.writeExprs(w, true) })
 }
 
 func (e Exprs) simpleExprs() bool {
@@ -795,6 +1373,11 @@ func (e Exprs) simpleExprs() bool {
 }
 
 func (e Exprs) writeExprs(w *code.Writer, singleLine bool) {
+	e.writeList(w, singleLine, "")
+}
+
+// writeList writes the expressions, the last one followed by suffix.
+func (e Exprs) writeList(w *code.Writer, singleLine bool, suffix string) {
 	if !singleLine {
 		// Make an attempt to fit a single line
 		singleLine = true
@@ -804,6 +1387,8 @@ func (e Exprs) writeExprs(w *code.Writer, singleLine bool) {
 				singleLine = false
 			}
 		}
+
+		singleLine = singleLine && w.FitsLine(1, func(w *code.Writer) { e.writeList(w, true, suffix) })
 	}
 
 	if singleLine {
@@ -814,11 +1399,18 @@ func (e Exprs) writeExprs(w *code.Writer, singleLine bool) {
 
 			writeExpr(w, itm, singleLine, "expression in list must not be nil")
 		}
+
+		w.WriteString(suffix)
 	} else {
 		w.Newline()
 		w.Indent(func(w *code.Writer) {
-			for _, itm := range e {
+			for idx, itm := range e {
 				writeExpr(w, itm, singleLine, "expression in list must not be nil")
+
+				if idx == len(e)-1 {
+					w.WriteString(suffix)
+				}
+
 				w.WriteByte(',')
 				w.Newline()
 			}
@@ -834,8 +1426,12 @@ var (
 	_ Expr = IntExpr(0)
 	_ Expr = UintExpr(0)
 	_ Expr = FloatExpr(0)
+	_ Expr = ExpFloatExpr(0)
 	_ Expr = RuneExpr('r')
 	_ Expr = StringExpr("")
+	_ Expr = RawStringExpr("")
+	_ Expr = IntFormatExpr{}
+	_ Expr = BytesExpr(nil)
 	_ Expr = ParExpr{}
 	_ Expr = CastExpr{}
 	_ Expr = SliceExpr{}
@@ -846,6 +1442,7 @@ var (
 	_ Expr = MakeExpr{}
 	_ Expr = MemberExpr{}
 	_ Expr = CallExpr{}
+	_ Expr = TypeAssertExpr{}
 	_ Expr = IndexExpr{}
 	_ Expr = RangeExpr{}
 	_ Expr = IdentExpr{}
@@ -854,6 +1451,7 @@ var (
 	_ Expr = ComplementExpr{}
 	_ Expr = AddrOfExpr{}
 	_ Expr = DerefExpr{}
+	_ Expr = RecvExpr{}
 	_ Expr = AddExpr{}
 	_ Expr = SubtractExpr{}
 	_ Expr = MultiplyExpr{}
@@ -868,6 +1466,7 @@ var (
 	_ Expr = MoreThanExpr{}
 	_ Expr = MoreOrEqualExpr{}
 	_ Expr = BitAndExpr{}
+	_ Expr = BitClearExpr{}
 	_ Expr = BitXorExpr{}
 	_ Expr = BitOrExpr{}
 	_ Expr = LogAndExpr{}
@@ -0,0 +1,498 @@
+package golang
+
+import (
+	"errors"
+	"fmt"
+	"go/types"
+	"slices"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+type (
+	// Loader resolves the types and functions declared by packages from their source, unlike SymbolFor
+	// which requires the types to be linked into the generator. Packages are loaded with
+	// golang.org/x/tools/go/packages, which runs the go command in Dir, type-checked from source along
+	// with their dependencies, and cached by path. The go command resolves modules from the module cache;
+	// setting GOFLAGS=-mod=mod and GOPROXY=off in Env keeps it offline.
+	Loader struct {
+		// Dir is the directory of the module the packages are resolved from; the current directory if empty.
+		Dir string
+		// Env is the environment of the go command; the current environment if nil.
+		Env []string
+
+		pkgs map[string]*types.Package
+	}
+
+	// LoadedType is a named type declared by a loaded package.
+	LoadedType struct {
+		// Symbol references the type by the path of its package.
+		Symbol    Symbol
+		GenParams GenParams
+		// Spec is the underlying type, or the target of an alias.
+		Spec  Type
+		Alias bool
+		// Meths is the method set of a pointer to the type, including the promoted methods, sorted by name.
+		Meths []LoadedFunc
+	}
+
+	// LoadedFunc is the signature of a function, or of a method, declared by a loaded package.
+	LoadedFunc struct {
+		// Symbol references the function by the path of its package; it only holds the ID of a method.
+		Symbol    Symbol
+		GenParams GenParams
+		Params    Params
+		Return    Params
+		// PtrRecv is set for methods that are not in the method set of the type itself.
+		PtrRecv bool
+	}
+)
+
+// Type returns the type id declared by the package path.
+func (l *Loader) Type(path string, id ID) (LoadedType, error) {
+	obj, err := l.lookup(path, id)
+	if err != nil {
+		return LoadedType{}, err
+	}
+
+	tn, ok := obj.(*types.TypeName)
+	if !ok {
+		return LoadedType{}, fmt.Errorf("'%s.%s' is not a type", path, id)
+	}
+
+	return loadedType(tn)
+}
+
+// Types returns the exported types declared by the package path, sorted by name.
+func (l *Loader) Types(path string) ([]LoadedType, error) {
+	pkg, err := l.load(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var res []LoadedType
+
+	for _, name := range pkg.Scope().Names() {
+		if tn, ok := pkg.Scope().Lookup(name).(*types.TypeName); ok && tn.Exported() {
+			itm, err := loadedType(tn)
+			if err != nil {
+				return nil, err
+			}
+
+			res = append(res, itm)
+		}
+	}
+
+	return res, nil
+}
+
+// Func returns the function id declared by the package path.
+func (l *Loader) Func(path string, id ID) (LoadedFunc, error) {
+	obj, err := l.lookup(path, id)
+	if err != nil {
+		return LoadedFunc{}, err
+	}
+
+	fn, ok := obj.(*types.Func)
+	if !ok {
+		return LoadedFunc{}, fmt.Errorf("'%s.%s' is not a function", path, id)
+	}
+
+	res, err := loadedFunc(fn.Type().(*types.Signature))
+	res.Symbol = Symbol{Path: path, ID: id}
+
+	return res, err
+}
+
+// Funcs returns the exported functions declared by the package path, sorted by name.
+func (l *Loader) Funcs(path string) ([]LoadedFunc, error) {
+	pkg, err := l.load(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var res []LoadedFunc
+
+	for _, name := range pkg.Scope().Names() {
+		if fn, ok := pkg.Scope().Lookup(name).(*types.Func); ok && fn.Exported() {
+			itm, err := loadedFunc(fn.Type().(*types.Signature))
+			if err != nil {
+				return nil, fmt.Errorf("function '%s': %w", name, err)
+			}
+
+			itm.Symbol = Symbol{Path: path, ID: ID(name)}
+			res = append(res, itm)
+		}
+	}
+
+	return res, nil
+}
+
+func (l *Loader) lookup(path string, id ID) (types.Object, error) {
+	pkg, err := l.load(path)
+	if err != nil {
+		return nil, err
+	}
+
+	obj := pkg.Scope().Lookup(string(id))
+	if obj == nil {
+		return nil, fmt.Errorf("package '%s' does not declare '%s'", path, id)
+	}
+
+	return obj, nil
+}
+
+func (l *Loader) load(path string) (*types.Package, error) {
+	if pkg, ok := l.pkgs[path]; ok {
+		return pkg, nil
+	}
+
+	cfg := packages.Config{
+		Mode: packages.NeedName | packages.NeedTypes | packages.NeedSyntax | packages.NeedImports | packages.NeedDeps,
+		Dir:  l.Dir,
+		Env:  l.Env,
+	}
+
+	pkgs, err := packages.Load(&cfg, path)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(pkgs) != 1 {
+		return nil, fmt.Errorf("package '%s' matches %d packages", path, len(pkgs))
+	}
+
+	if len(pkgs[0].Errors) > 0 {
+		errs := make([]error, len(pkgs[0].Errors))
+
+		for idx, itm := range pkgs[0].Errors {
+			errs[idx] = itm
+		}
+
+		return nil, fmt.Errorf("loading package '%s': %w", path, errors.Join(errs...))
+	}
+
+	if l.pkgs == nil {
+		l.pkgs = make(map[string]*types.Package)
+	}
+
+	l.pkgs[path] = pkgs[0].Types
+	return pkgs[0].Types, nil
+}
+
+func loadedType(tn *types.TypeName) (LoadedType, error) {
+	res := LoadedType{
+		Symbol: Symbol{Path: tn.Pkg().Path(), ID: ID(tn.Name())},
+		Alias:  tn.IsAlias(),
+	}
+
+	wrap := func(err error) (LoadedType, error) {
+		return LoadedType{}, fmt.Errorf("type '%s': %w", tn.Name(), err)
+	}
+
+	if res.Alias {
+		alias, ok := tn.Type().(*types.Alias)
+		if !ok {
+			return wrap(errors.New("alias is not materialized (GODEBUG gotypesalias=0)"))
+		}
+
+		gen, err := genParams(alias.TypeParams())
+		if err != nil {
+			return wrap(err)
+		}
+
+		res.GenParams = gen
+		res.Spec, err = typeOf(alias.Rhs())
+		if err != nil {
+			return wrap(err)
+		}
+
+		return res, nil
+	}
+
+	named, ok := tn.Type().(*types.Named)
+	if !ok {
+		return wrap(errors.New("not a named type"))
+	}
+
+	gen, err := genParams(named.TypeParams())
+	if err != nil {
+		return wrap(err)
+	}
+
+	res.GenParams = gen
+	res.Spec, err = typeOf(named.Underlying())
+	if err != nil {
+		return wrap(err)
+	}
+
+	values := types.NewMethodSet(named)
+	ptrs := types.NewMethodSet(types.NewPointer(named))
+
+	for idx := range ptrs.Len() {
+		fn := ptrs.At(idx).Obj().(*types.Func)
+
+		if !fn.Exported() {
+			continue
+		}
+
+		meth, err := loadedFunc(fn.Type().(*types.Signature))
+		if err != nil {
+			return wrap(fmt.Errorf("method '%s': %w", fn.Name(), err))
+		}
+
+		meth.Symbol = Symbol{ID: ID(fn.Name())}
+		meth.PtrRecv = values.Lookup(fn.Pkg(), fn.Name()) == nil
+		res.Meths = append(res.Meths, meth)
+	}
+
+	slices.SortFunc(res.Meths, func(a, b LoadedFunc) int { return strings.Compare(string(a.Symbol.ID), string(b.Symbol.ID)) })
+	return res, nil
+}
+
+func loadedFunc(sig *types.Signature) (LoadedFunc, error) {
+	gen, err := genParams(sig.TypeParams())
+	if err != nil {
+		return LoadedFunc{}, err
+	}
+
+	params, err := tupleParams(sig.Params())
+	if err != nil {
+		return LoadedFunc{}, err
+	}
+
+	if sig.Variadic() {
+		// the last parameter is typed as a slice of the values it receives
+		last := &params[len(params)-1]
+		last.Type, last.Variadic = last.Type.(SliceType).Items, true
+	}
+
+	ret, err := tupleParams(sig.Results())
+	if err != nil {
+		return LoadedFunc{}, err
+	}
+
+	return LoadedFunc{GenParams: gen, Params: params, Return: ret}, nil
+}
+
+func genParams(list *types.TypeParamList) (GenParams, error) {
+	var res GenParams
+
+	for idx := range list.Len() {
+		param := list.At(idx)
+		itm := GenParam{ID: ID(param.Obj().Name())}
+
+		cnst, err := constraintOf(param.Constraint())
+		if err != nil {
+			return nil, err
+		}
+
+		if len(cnst) == 1 {
+			itm.Const = cnst[0]
+		} else {
+			itm.Union = cnst
+		}
+
+		res = append(res, itm)
+	}
+
+	return res, nil
+}
+
+// constraintOf returns the terms of the constraint t; implicit interfaces, as in [T ~int | ~string],
+// are reduced to their union.
+func constraintOf(t types.Type) (GenConsts, error) {
+	if iface, ok := t.(*types.Interface); ok && iface.IsImplicit() && iface.NumEmbeddeds() == 1 {
+		return unionOf(iface.EmbeddedType(0))
+	}
+
+	base, err := typeOf(t)
+	if err != nil {
+		return nil, err
+	}
+
+	return GenConsts{{Base: base}}, nil
+}
+
+func unionOf(t types.Type) (GenConsts, error) {
+	union, ok := t.(*types.Union)
+	if !ok {
+		base, err := typeOf(t)
+		return GenConsts{{Base: base}}, err
+	}
+
+	res := make(GenConsts, union.Len())
+
+	for idx := range union.Len() {
+		term := union.Term(idx)
+
+		base, err := typeOf(term.Type())
+		if err != nil {
+			return nil, err
+		}
+
+		res[idx] = GenConst{Tilde: term.Tilde(), Base: base}
+	}
+
+	return res, nil
+}
+
+func tupleParams(tuple *types.Tuple) (Params, error) {
+	var res Params
+
+	for idx := range tuple.Len() {
+		v := tuple.At(idx)
+
+		typ, err := typeOf(v.Type())
+		if err != nil {
+			return nil, err
+		}
+
+		res = append(res, Param{ID: ID(v.Name()), Type: typ})
+	}
+
+	return res, nil
+}
+
+// typeOf converts t; named types become symbols referencing their package by path.
+func typeOf(t types.Type) (Type, error) {
+	switch t := t.(type) {
+	case *types.Basic:
+		switch {
+		case t.Kind() == types.UnsafePointer:
+			return Symbol{Path: "unsafe", ID: "Pointer"}, nil
+		case t.Info()&types.IsUntyped != 0:
+			return nil, fmt.Errorf("untyped type %s cannot be represented", t)
+		}
+
+		return Symbol{ID: ID(t.Name())}, nil
+	case *types.Named:
+		return objectSymbol(t.Obj(), t.TypeArgs())
+	case *types.Alias:
+		return objectSymbol(t.Obj(), t.TypeArgs())
+	case *types.TypeParam:
+		return Symbol{ID: ID(t.Obj().Name())}, nil
+	case *types.Pointer:
+		item, err := typeOf(t.Elem())
+		return PtrType{Item: item}, err
+	case *types.Slice:
+		items, err := typeOf(t.Elem())
+		return SliceType{Items: items}, err
+	case *types.Array:
+		items, err := typeOf(t.Elem())
+		return SliceType{Items: items, Size: IntExpr(t.Len())}, err
+	case *types.Map:
+		key, err := typeOf(t.Key())
+		if err != nil {
+			return nil, err
+		}
+
+		val, err := typeOf(t.Elem())
+		return MapType{Key: key, Value: val}, err
+	case *types.Chan:
+		res := ChanType{}
+
+		switch t.Dir() {
+		case types.SendOnly:
+			res.Dir = ChanSend
+		case types.RecvOnly:
+			res.Dir = ChanRecv
+		}
+
+		item, err := typeOf(t.Elem())
+		res.Item = item
+
+		return res, err
+	case *types.Signature:
+		fn, err := loadedFunc(t)
+		return FuncType{Params: fn.Params, Return: fn.Return}, err
+	case *types.Struct:
+		return structOf(t)
+	case *types.Interface:
+		return interfaceOf(t)
+	}
+
+	return nil, fmt.Errorf("type %s cannot be represented", t)
+}
+
+func objectSymbol(obj *types.TypeName, args *types.TypeList) (Type, error) {
+	res := Symbol{ID: ID(obj.Name())}
+
+	// the predeclared error and comparable types have no package
+	if obj.Pkg() != nil {
+		res.Path = obj.Pkg().Path()
+	}
+
+	for idx := range args.Len() {
+		arg, err := typeOf(args.At(idx))
+		if err != nil {
+			return nil, err
+		}
+
+		res.GenArgs = append(res.GenArgs, arg)
+	}
+
+	return res, nil
+}
+
+func structOf(t *types.Struct) (Type, error) {
+	res := StructType{}
+
+	for idx := range t.NumFields() {
+		fld := t.Field(idx)
+
+		typ, err := typeOf(fld.Type())
+		if err != nil {
+			return nil, err
+		}
+
+		if fld.Embedded() {
+			res.Bases = append(res.Bases, typ)
+			continue
+		}
+
+		tags, ok := parseTags(t.Tag(idx))
+		if !ok {
+			return nil, fmt.Errorf("struct tag of field '%s' cannot be represented", fld.Name())
+		}
+
+		res.Fields = append(res.Fields, StructField{ID: ID(fld.Name()), Type: typ, Tags: tags})
+	}
+
+	return res, nil
+}
+
+func interfaceOf(t *types.Interface) (Type, error) {
+	if t.Empty() {
+		return Any, nil
+	}
+
+	res := InterfaceType{}
+
+	for idx := range t.NumEmbeddeds() {
+		cnst, err := unionOf(t.EmbeddedType(idx))
+		if err != nil {
+			return nil, err
+		}
+
+		if idx == 0 {
+			res.Consts = cnst
+		} else {
+			res.Unions = append(res.Unions, cnst)
+		}
+	}
+
+	for idx := range t.NumExplicitMethods() {
+		fn := t.ExplicitMethod(idx)
+
+		sig, err := loadedFunc(fn.Type().(*types.Signature))
+		if err != nil {
+			return nil, fmt.Errorf("method '%s': %w", fn.Name(), err)
+		}
+
+		res.Meths = append(res.Meths, InterfaceMeth{ID: ID(fn.Name()), Params: sig.Params, Return: sig.Return})
+	}
+
+	return res, nil
+}
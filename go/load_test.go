@@ -0,0 +1,103 @@
+package golang_test
+
+import (
+	"testing"
+
+	code "github.com/trwk76/go-code"
+	golang "github.com/trwk76/go-code/go"
+)
+
+func TestLoader(t *testing.T) {
+	const path = "github.com/trwk76/go-code/go/testdata/loaded"
+
+	var loader golang.Loader
+
+	set, err := loader.Type(path, "Set")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(set.Meths) != 3 || set.Meths[0].Symbol.ID != "Add" || !set.Meths[0].PtrRecv || !set.Meths[1].Params[0].Variadic || set.Meths[2].PtrRecv {
+		t.Errorf("unexpected method set: %#v", set.Meths)
+	}
+
+	types, err := loader.Types(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	funcs, err := loader.Funcs(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	unit := golang.Unit{Package: "my"}
+	decls := golang.TypeDecls{}
+
+	for _, itm := range types {
+		decl := golang.TypeDecl{ID: itm.Symbol.ID, GenParams: itm.GenParams, Spec: itm.Spec}
+		if itm.Alias {
+			decl.Spec = golang.TypeAlias{Target: itm.Spec}
+		}
+
+		decls = append(decls, decl)
+	}
+
+	wrappers := golang.FuncDecls{}
+
+	for _, itm := range funcs {
+		call := golang.CallExpr{Func: itm.Symbol, Spread: itm.Params[len(itm.Params)-1].Variadic}
+		for _, param := range itm.Params {
+			call.Args = append(call.Args, golang.Symbol{ID: param.ID})
+		}
+
+		wrappers = append(wrappers, golang.Func(itm.Symbol.ID).GenParams(itm.GenParams...).Params(itm.Params...).Returns(itm.Return[0].Type).Body(golang.Return(call)))
+	}
+
+	unit.Decls = golang.Decls{decls, wrappers}
+
+	text := code.WriteString("\t", func(w *code.Writer) {
+		if err := unit.Write(w); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	if text != `package my
+
+import (
+	io "io"
+	time "time"
+
+	loaded "github.com/trwk76/go-code/go/testdata/loaded"
+)
+
+type (
+	Entry struct {
+		io.Reader
+
+		Name    string `+"`json:\"name\"`"+`
+		Timeout time.Duration
+	}
+
+	ID = string
+
+	Number interface {
+		~int | ~int64 | ~float64
+	}
+
+	Set[T comparable] struct {
+		items map[T]bool
+	}
+)
+
+func Join(sep string, parts ...string) string       { return loaded.Join(sep, parts...) }
+func NewSet[T comparable](items []T) *loaded.Set[T] { return loaded.NewSet(items) }
+func Sum[N ~int | ~float64](values []N) N           { return loaded.Sum(values) }
+` {
+		t.Errorf("unexpected output:\n%s", text)
+	}
+
+	if _, err := loader.Func(path, "Entry"); err == nil {
+		t.Error("expected types not to be loaded as functions")
+	}
+}
@@ -0,0 +1,1680 @@
+package golang
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/build/constraint"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"strconv"
+	"strings"
+
+	code "github.com/trwk76/go-code"
+)
+
+// ParseFile parses the Go source file filename into a unit; src is interpreted as by go/parser.ParseFile.
+// Syntax errors, and the nodes that cannot be represented by this package, are reported as ParseErrors.
+// In the latter case, the returned unit holds everything else the file declares.
+// Regions are delimited by code.DefaultMarkers; see ParseFileMarkers for other markers.
+func ParseFile(filename string, src any) (Unit, error) {
+	return ParseFileMarkers(filename, src, code.DefaultMarkers)
+}
+
+// ParseFileMarkers is like ParseFile for a file whose regions are delimited by m (see code.Writer.SetMarkers).
+func ParseFileMarkers(filename string, src any, m code.Markers) (Unit, error) {
+	fset := token.NewFileSet()
+
+	file, err := parser.ParseFile(fset, filename, src, parser.ParseComments|parser.AllErrors)
+	if err != nil {
+		return Unit{}, syntaxErrors(err)
+	}
+
+	r := newAstReader(fset)
+	r.markers = m
+	res := r.unit(file)
+
+	return res, r.result()
+}
+
+// ParseExpr parses a Go expression.
+func ParseExpr(src string) (Expr, error) {
+	fset := token.NewFileSet()
+
+	expr, err := parser.ParseExprFrom(fset, "", src, 0)
+	if err != nil {
+		return nil, syntaxErrors(err)
+	}
+
+	r := newAstReader(fset)
+	res := r.expr(expr)
+
+	return res, r.result()
+}
+
+// ParseStmts parses a list of Go statements, as found in a function body.
+func ParseStmts(src string) (BlockStmt, error) {
+	const prefix = "package p; func _() {\n"
+
+	fset := token.NewFileSet()
+
+	file, err := parser.ParseFile(fset, "", prefix+src+"\n}", parser.ParseComments|parser.AllErrors)
+	if err != nil {
+		return nil, syntaxErrors(err)
+	}
+
+	r := newAstReader(fset)
+	r.groups = file.Comments
+	res := r.block(file.Decls[0].(*ast.FuncDecl).Body)
+
+	for _, grp := range file.Comments {
+		r.failComment(grp)
+	}
+
+	return res, r.result()
+}
+
+type (
+	// ParseError is a syntax error, or a node that cannot be represented by this package.
+	ParseError struct {
+		Pos token.Position
+		Msg string
+	}
+
+	ParseErrors []ParseError
+
+	// astReader converts a go/ast tree; the nodes it cannot convert are recorded in errs and left out.
+	astReader struct {
+		fset     *token.FileSet
+		imports  map[string]PkgRef
+		groups   []*ast.CommentGroup
+		comments map[*ast.Comment]bool
+		errs     ParseErrors
+		// paths maps the names of the packages imported by a template to their path.
+		paths map[string]string
+		// args holds the arguments of the template being executed; placeholders are read as
+		// plain identifiers when it is nil.
+		args TemplateArgs
+		// markers delimit the regions of the source.
+		markers code.Markers
+	}
+
+	// regionFrame collects the items of a region being read.
+	regionFrame[T any] struct {
+		name  string
+		begin *ast.Comment
+		items []T
+	}
+)
+
+func (e ParseError) Error() string {
+	if e.Pos.IsValid() {
+		return e.Pos.String() + ": " + e.Msg
+	}
+
+	return e.Msg
+}
+
+func (e ParseErrors) Error() string {
+	items := make([]string, len(e))
+
+	for idx, itm := range e {
+		items[idx] = itm.Error()
+	}
+
+	return strings.Join(items, "\n")
+}
+
+func syntaxErrors(err error) error {
+	var list scanner.ErrorList
+
+	if !errors.As(err, &list) {
+		return err
+	}
+
+	res := make(ParseErrors, len(list))
+
+	for idx, itm := range list {
+		res[idx] = ParseError{Pos: itm.Pos, Msg: itm.Msg}
+	}
+
+	return res
+}
+
+func newAstReader(fset *token.FileSet) *astReader {
+	return &astReader{
+		fset:     fset,
+		imports:  make(map[string]PkgRef),
+		comments: make(map[*ast.Comment]bool),
+		markers:  code.DefaultMarkers,
+	}
+}
+
+func (r *astReader) result() error {
+	if len(r.errs) > 0 {
+		return r.errs
+	}
+
+	return nil
+}
+
+func (r *astReader) fail(n ast.Node, format string, args ...any) {
+	r.errs = append(r.errs, ParseError{
+		Pos: r.fset.Position(n.Pos()),
+		Msg: fmt.Sprintf(format, args...),
+	})
+}
+
+func (r *astReader) failComment(grp *ast.CommentGroup) {
+	if !r.consumed(grp) {
+		r.consume(grp)
+		r.fail(grp, "comment cannot be represented at this position")
+	}
+}
+
+// consumed returns whether all the comments of grp have been converted or reported.
+func (r *astReader) consumed(grp *ast.CommentGroup) bool {
+	for _, itm := range grp.List {
+		if !r.comments[itm] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (r *astReader) consume(grp *ast.CommentGroup) {
+	for _, itm := range grp.List {
+		r.comments[itm] = true
+	}
+}
+
+func (r *astReader) unit(file *ast.File) Unit {
+	res := Unit{Package: PkgName(file.Name.Name)}
+	next := 0
+
+	r.groups = file.Comments
+
+	// comments preceding the package clause form the prefix
+	for ; next < len(file.Comments) && file.Comments[next].Pos() < file.Package; next++ {
+		grp := file.Comments[next]
+
+		if grp == file.Doc {
+			res.Doc = Doc{Blocks: []DocBlock{r.comment(file.Doc)}}
+			continue
+		}
+
+		if len(grp.List) == 1 && res.Prefix == "" && res.Build == "" && res.Generator == "" {
+			if m := generatedHeader.FindStringSubmatch(grp.List[0].Text); m != nil {
+				r.consume(grp)
+				res.Generator = m[1]
+				continue
+			}
+		}
+
+		if len(grp.List) == 1 && strings.HasPrefix(grp.List[0].Text, "//go:build ") {
+			expr, err := constraint.Parse(grp.List[0].Text)
+			if err != nil {
+				r.fail(grp, "invalid build constraint: %s", err.Error())
+			} else {
+				res.Build = expr.String()
+			}
+
+			r.consume(grp)
+			continue
+		}
+
+		if res.Prefix != "" {
+			res.Prefix += "\n"
+		}
+
+		res.Prefix += r.comment(file.Comments[next])
+	}
+
+	for _, decl := range file.Decls {
+		if decl, ok := decl.(*ast.GenDecl); ok && decl.Tok == token.IMPORT {
+			r.importDecl(&res, decl)
+		}
+	}
+
+	stack := []regionFrame[Decl]{{}}
+	wrap := func(name string, items []Decl) Decl { return RegionDecl{Name: name, Decls: items} }
+
+	// free-standing comments between declarations are kept as Comment declarations
+	freeComment := func(grp *ast.CommentGroup) {
+		if r.consumed(grp) {
+			return
+		}
+
+		if !r.hasMarker(grp) {
+			top := &stack[len(stack)-1]
+
+			if len(grp.List) == 1 && strings.HasPrefix(grp.List[0].Text, "/*") {
+				r.consume(grp)
+				top.items = append(top.items, BlockComment(strings.TrimSuffix(grp.List[0].Text[2:], "*/")))
+				return
+			}
+
+			top.items = append(top.items, r.comment(grp))
+			return
+		}
+
+		for _, itm := range grp.List {
+			if r.isMarker(itm) {
+				stack = readMarker(r, stack, itm, wrap)
+			} else {
+				r.comments[itm] = true
+				r.fail(itm, "comment cannot be represented at this position")
+			}
+		}
+	}
+
+	for _, decl := range file.Decls {
+		for ; next < len(file.Comments) && file.Comments[next].End() <= decl.Pos(); next++ {
+			if grp := file.Comments[next]; !isDocOf(grp, decl) {
+				freeComment(grp)
+			}
+		}
+
+		top := &stack[len(stack)-1]
+		top.items = r.decl(top.items, decl)
+
+		for ; next < len(file.Comments) && file.Comments[next].Pos() < decl.End(); next++ {
+			r.failComment(file.Comments[next])
+		}
+	}
+
+	for ; next < len(file.Comments); next++ {
+		freeComment(file.Comments[next])
+	}
+
+	res.Decls = closeRegions(r, stack, wrap)
+	return res
+}
+
+func isDocOf(grp *ast.CommentGroup, decl ast.Decl) bool {
+	switch decl := decl.(type) {
+	case *ast.FuncDecl:
+		return decl.Doc == grp
+	case *ast.GenDecl:
+		return decl.Doc == grp
+	}
+
+	return false
+}
+
+func (r *astReader) comment(grp *ast.CommentGroup) Comment {
+	if grp == nil {
+		return ""
+	}
+
+	r.consume(grp)
+	lines := make([]string, 0, len(grp.List))
+
+	for _, itm := range grp.List {
+		if !strings.HasPrefix(itm.Text, "//") {
+			r.fail(itm, "block comments cannot be represented at this position")
+			continue
+		}
+
+		lines = append(lines, itm.Text[2:])
+	}
+
+	return Comment(strings.Join(lines, "\n"))
+}
+
+func (r *astReader) importDecl(unit *Unit, decl *ast.GenDecl) {
+	if len(decl.Specs) == 1 && decl.Specs[0].(*ast.ImportSpec).Path.Value == `"C"` {
+		spec := decl.Specs[0].(*ast.ImportSpec)
+		r.noComment(spec.Comment)
+
+		if spec.Name != nil || decl.Doc == nil || unit.Cgo != "" {
+			r.fail(decl, "cgo import cannot be represented")
+			return
+		}
+
+		r.consume(decl.Doc)
+		unit.Cgo = strings.TrimSpace(decl.Doc.Text())
+		return
+	}
+
+	if decl.Lparen.IsValid() {
+		r.noComment(decl.Doc)
+	}
+
+	for _, spec := range decl.Specs {
+		doc := spec.(*ast.ImportSpec).Doc
+
+		if !decl.Lparen.IsValid() {
+			// the documentation of an unparenthesized import is the one of its declaration
+			doc = decl.Doc
+		}
+
+		r.importSpec(&unit.Imports, spec.(*ast.ImportSpec), doc)
+	}
+}
+
+func (r *astReader) importSpec(imps *Imports, spec *ast.ImportSpec, doc *ast.CommentGroup) {
+	r.noComment(spec.Comment)
+
+	path, err := strconv.Unquote(spec.Path.Value)
+	if err != nil {
+		r.fail(spec.Path, "invalid import path %s", spec.Path.Value)
+		return
+	}
+
+	alias := ""
+	if spec.Name != nil {
+		alias = spec.Name.Name
+	}
+
+	ref, err := imps.ensure(PkgName(alias), path)
+	if err != nil {
+		r.fail(spec, "%s", err.Error())
+		return
+	}
+
+	if doc != nil {
+		imps.Comment(path, r.comment(doc))
+	}
+
+	if ref.alias != PkgName(Ignore) && ref.alias != dotImport {
+		r.imports[string(ref.alias)] = ref
+	}
+}
+
+func (r *astReader) noComment(grp *ast.CommentGroup) {
+	if grp != nil {
+		r.failComment(grp)
+	}
+}
+
+func (r *astReader) decl(decls Decls, decl ast.Decl) Decls {
+	switch decl := decl.(type) {
+	case *ast.FuncDecl:
+		if decl.Recv == nil {
+			itm, ok := r.funcDecl(decl)
+			if !ok {
+				return decls
+			}
+
+			if last, ok := lastDecl[FuncDecls](decls); ok {
+				decls[len(decls)-1] = append(last, itm)
+				return decls
+			}
+
+			return append(decls, FuncDecls{itm})
+		}
+
+		itm, ok := r.methDecl(decl)
+		if !ok {
+			return decls
+		}
+
+		if last, ok := lastDecl[MethDecls](decls); ok {
+			decls[len(decls)-1] = append(last, itm)
+			return decls
+		}
+
+		return append(decls, MethDecls{itm})
+	case *ast.GenDecl:
+		switch decl.Tok {
+		case token.IMPORT:
+			// handled by unit
+			return decls
+		case token.CONST:
+			return append(decls, ConstDecls(r.constSpecs(decl)))
+		case token.TYPE:
+			return append(decls, TypeDecls(r.typeSpecs(decl)))
+		case token.VAR:
+			return append(decls, VarDecls(r.varSpecs(decl)))
+		}
+	}
+
+	r.fail(decl, "unsupported declaration %T", decl)
+	return decls
+}
+
+func lastDecl[T Decl](decls Decls) (T, bool) {
+	if len(decls) < 1 {
+		var zero T
+		return zero, false
+	}
+
+	res, ok := decls[len(decls)-1].(T)
+	return res, ok
+}
+
+func (r *astReader) funcDecl(decl *ast.FuncDecl) (FuncDecl, bool) {
+	if decl.Body == nil {
+		r.fail(decl, "function declarations without a body cannot be represented")
+		return FuncDecl{}, false
+	}
+
+	return FuncDecl{
+		Comment:   r.comment(decl.Doc),
+		ID:        r.id(decl.Name),
+		GenParams: r.genParams(decl.Type.TypeParams),
+		Params:    r.params(decl.Type.Params),
+		Return:    r.params(decl.Type.Results),
+		Body:      r.block(decl.Body),
+	}, true
+}
+
+func (r *astReader) methDecl(decl *ast.FuncDecl) (MethDecl, bool) {
+	if decl.Body == nil {
+		r.fail(decl, "method declarations without a body cannot be represented")
+		return MethDecl{}, false
+	}
+
+	recv := r.params(decl.Recv)
+	if len(recv) != 1 {
+		r.fail(decl.Recv, "method requires exactly one receiver")
+		return MethDecl{}, false
+	}
+
+	return MethDecl{
+		Comment:  r.comment(decl.Doc),
+		Receiver: recv[0],
+		ID:       r.id(decl.Name),
+		Params:   r.params(decl.Type.Params),
+		Return:   r.params(decl.Type.Results),
+		Body:     r.block(decl.Body),
+	}, true
+}
+
+func (r *astReader) constSpecs(decl *ast.GenDecl) []ConstDecl {
+	res := make([]ConstDecl, 0, len(decl.Specs))
+
+	for _, spec := range decl.Specs {
+		spec := spec.(*ast.ValueSpec)
+		r.noComment(spec.Comment)
+
+		itm := ConstDecl{
+			Comment: r.specComment(decl, spec.Doc),
+			Type:    r.optType(spec.Type),
+		}
+
+		itm.ID, itm.IDs = r.valueIDs(spec.Names)
+		itm.Value, itm.Values = r.values(spec.Values, len(spec.Names))
+		res = append(res, itm)
+	}
+
+	return res
+}
+
+func (r *astReader) varSpecs(decl *ast.GenDecl) []VarDecl {
+	res := make([]VarDecl, 0, len(decl.Specs))
+
+	for _, spec := range decl.Specs {
+		spec := spec.(*ast.ValueSpec)
+		r.noComment(spec.Comment)
+
+		itm := VarDecl{
+			Comment: r.specComment(decl, spec.Doc),
+			Type:    r.optType(spec.Type),
+		}
+
+		itm.ID, itm.IDs = r.valueIDs(spec.Names)
+		itm.Value, itm.Values = r.values(spec.Values, len(spec.Names))
+		res = append(res, itm)
+	}
+
+	return res
+}
+
+func (r *astReader) typeSpecs(decl *ast.GenDecl) []TypeDecl {
+	res := make([]TypeDecl, 0, len(decl.Specs))
+
+	for _, spec := range decl.Specs {
+		spec := spec.(*ast.TypeSpec)
+		r.noComment(spec.Comment)
+
+		itm := TypeDecl{
+			Comment:   r.specComment(decl, spec.Doc),
+			ID:        r.id(spec.Name),
+			GenParams: r.genParams(spec.TypeParams),
+		}
+
+		if spec.Assign.IsValid() {
+			itm.Spec = TypeAlias{Target: r.typ(spec.Type)}
+		} else {
+			itm.Spec = r.typ(spec.Type)
+		}
+
+		res = append(res, itm)
+	}
+
+	return res
+}
+
+// specComment returns the comment of a spec; the documentation of an unparenthesized declaration
+// belongs to its only spec.
+func (r *astReader) specComment(decl *ast.GenDecl, doc *ast.CommentGroup) Comment {
+	if !decl.Lparen.IsValid() {
+		return r.comment(decl.Doc)
+	}
+
+	r.noComment(decl.Doc)
+	return r.comment(doc)
+}
+
+func (r *astReader) valueIDs(names []*ast.Ident) (ID, []ID) {
+	if len(names) == 1 {
+		return r.id(names[0]), nil
+	}
+
+	return "", r.ids(names)
+}
+
+func (r *astReader) values(exprs []ast.Expr, count int) (Expr, Exprs) {
+	switch {
+	case len(exprs) < 1:
+		return nil, nil
+	case count == 1 && len(exprs) == 1:
+		return r.expr(exprs[0]), nil
+	}
+
+	return nil, r.exprs(exprs)
+}
+
+func (r *astReader) ids(names []*ast.Ident) []ID {
+	res := make([]ID, len(names))
+
+	for idx, itm := range names {
+		res[idx] = r.id(itm)
+	}
+
+	return res
+}
+
+// genParams converts a type parameter list; the names sharing a constraint are kept grouped.
+func (r *astReader) genParams(list *ast.FieldList) GenParams {
+	if list == nil {
+		return nil
+	}
+
+	var res GenParams
+
+	for _, fld := range list.List {
+		var last GenParam
+
+		if bin, ok := fld.Type.(*ast.BinaryExpr); ok && bin.Op == token.OR {
+			last.Union = r.union(bin)
+		} else if cnst, ok := r.genConst(fld.Type); ok {
+			last.Const = cnst
+		} else {
+			continue
+		}
+
+		for idx, id := range fld.Names {
+			itm := GenParam{ID: r.id(id)}
+
+			if idx == len(fld.Names)-1 {
+				itm.Const, itm.Union = last.Const, last.Union
+			}
+
+			res = append(res, itm)
+		}
+	}
+
+	return res
+}
+
+func (r *astReader) genConst(expr ast.Expr) (GenConst, bool) {
+	if un, ok := expr.(*ast.UnaryExpr); ok && un.Op == token.TILDE {
+		return GenConst{Tilde: true, Base: r.typ(un.X)}, true
+	}
+
+	if bin, ok := expr.(*ast.BinaryExpr); ok && bin.Op == token.OR {
+		r.fail(expr, "nested union type constraints cannot be represented")
+		return GenConst{}, false
+	}
+
+	return GenConst{Base: r.typ(expr)}, true
+}
+
+// params converts a field list; the names sharing a type are kept grouped.
+func (r *astReader) params(list *ast.FieldList) Params {
+	if list == nil {
+		return nil
+	}
+
+	var res Params
+
+	for _, fld := range list.List {
+		var (
+			typ      Type
+			variadic bool
+		)
+
+		if ell, ok := fld.Type.(*ast.Ellipsis); ok {
+			typ, variadic = r.typ(ell.Elt), true
+		} else {
+			typ = r.typ(fld.Type)
+		}
+
+		if len(fld.Names) < 1 {
+			res = append(res, Param{Type: typ, Variadic: variadic})
+			continue
+		}
+
+		for idx, id := range fld.Names {
+			itm := Param{ID: r.id(id)}
+
+			if idx == len(fld.Names)-1 {
+				itm.Type, itm.Variadic = typ, variadic
+			}
+
+			res = append(res, itm)
+		}
+	}
+
+	return res
+}
+
+func (r *astReader) optType(expr ast.Expr) Type {
+	if expr == nil {
+		return nil
+	}
+
+	return r.typ(expr)
+}
+
+func (r *astReader) typ(expr ast.Expr) Type {
+	switch expr := expr.(type) {
+	case *ast.Ident:
+		if arg, ok := r.placeholder(expr); ok {
+			return r.typeArg(expr, arg)
+		}
+
+		if expr.Name == "nil" {
+			return Nil
+		}
+
+		return Symbol{ID: ID(expr.Name)}
+	case *ast.SelectorExpr:
+		if sym, ok := r.qualified(expr); ok {
+			return sym
+		}
+	case *ast.IndexExpr:
+		return r.genericType(expr, expr.X, []ast.Expr{expr.Index})
+	case *ast.IndexListExpr:
+		return r.genericType(expr, expr.X, expr.Indices)
+	case *ast.ParenExpr:
+		return r.typ(expr.X)
+	case *ast.StarExpr:
+		return PtrType{Item: r.typ(expr.X)}
+	case *ast.ArrayType:
+		if _, ok := expr.Len.(*ast.Ellipsis); ok {
+			r.fail(expr, "array types with an inferred length cannot be represented")
+			return nil
+		}
+
+		return SliceType{Items: r.typ(expr.Elt), Size: r.optExpr(expr.Len)}
+	case *ast.MapType:
+		return MapType{Key: r.typ(expr.Key), Value: r.typ(expr.Value)}
+	case *ast.ChanType:
+		res := ChanType{Dir: ChanBoth, Item: r.typ(expr.Value)}
+
+		switch expr.Dir {
+		case ast.RECV:
+			res.Dir = ChanRecv
+		case ast.SEND:
+			res.Dir = ChanSend
+		}
+
+		return res
+	case *ast.FuncType:
+		if expr.TypeParams != nil {
+			r.fail(expr, "function types cannot have type parameters")
+		}
+
+		return FuncType{Params: r.params(expr.Params), Return: r.params(expr.Results)}
+	case *ast.InterfaceType:
+		return r.interfaceType(expr)
+	case *ast.StructType:
+		return r.structType(expr)
+	}
+
+	r.fail(expr, "unsupported type %T", expr)
+	return nil
+}
+
+func (r *astReader) genericType(expr ast.Expr, base ast.Expr, args []ast.Expr) Type {
+	var sym Symbol
+
+	switch base := base.(type) {
+	case *ast.Ident:
+		sym = Symbol{ID: ID(base.Name)}
+	case *ast.SelectorExpr:
+		res, ok := r.qualified(base)
+		if !ok {
+			r.fail(expr, "unsupported generic type")
+			return nil
+		}
+
+		sym = res
+	default:
+		r.fail(expr, "unsupported generic type")
+		return nil
+	}
+
+	for _, itm := range args {
+		sym.GenArgs = append(sym.GenArgs, r.typ(itm))
+	}
+
+	return sym
+}
+
+// qualified returns the symbol for a selector naming a member of an imported package.
+func (r *astReader) qualified(expr *ast.SelectorExpr) (Symbol, bool) {
+	id, ok := expr.X.(*ast.Ident)
+	if !ok {
+		return Symbol{}, false
+	}
+
+	if path, ok := r.paths[id.Name]; ok {
+		// packages imported by a template are imported by the unit it is used in
+		return Symbol{Path: path, ID: ID(expr.Sel.Name)}, true
+	}
+
+	ref, ok := r.imports[id.Name]
+	if !ok {
+		return Symbol{}, false
+	}
+
+	return Symbol{Package: &ref, ID: ID(expr.Sel.Name)}, true
+}
+
+func (r *astReader) interfaceType(expr *ast.InterfaceType) Type {
+	res := InterfaceType{}
+
+	for _, fld := range expr.Methods.List {
+		r.noComment(fld.Comment)
+
+		if len(fld.Names) < 1 {
+			r.noComment(fld.Doc)
+
+			if res.Consts == nil {
+				res.Consts = r.union(fld.Type)
+			} else {
+				res.Unions = append(res.Unions, r.union(fld.Type))
+			}
+
+			continue
+		}
+
+		fnc, ok := fld.Type.(*ast.FuncType)
+		if !ok {
+			r.fail(fld, "unsupported interface method")
+			continue
+		}
+
+		cmt := r.comment(fld.Doc)
+
+		for _, id := range fld.Names {
+			res.Meths = append(res.Meths, InterfaceMeth{
+				Comment: cmt,
+				ID:      r.id(id),
+				Params:  r.params(fnc.Params),
+				Return:  r.params(fnc.Results),
+			})
+		}
+	}
+
+	return res
+}
+
+func (r *astReader) union(expr ast.Expr) GenConsts {
+	if bin, ok := expr.(*ast.BinaryExpr); ok && bin.Op == token.OR {
+		return append(r.union(bin.X), r.union(bin.Y)...)
+	}
+
+	cnst, ok := r.genConst(expr)
+	if !ok {
+		return nil
+	}
+
+	return GenConsts{cnst}
+}
+
+func (r *astReader) structType(expr *ast.StructType) Type {
+	res := StructType{}
+
+	for _, fld := range expr.Fields.List {
+		r.noComment(fld.Comment)
+		tags := r.tags(fld.Tag)
+
+		if len(fld.Names) < 1 {
+			r.noComment(fld.Doc)
+
+			if len(tags) > 0 {
+				r.fail(fld, "tags on embedded fields cannot be represented")
+			}
+
+			res.Bases = append(res.Bases, r.typ(fld.Type))
+			continue
+		}
+
+		typ := r.typ(fld.Type)
+		cmt := r.comment(fld.Doc)
+
+		for _, id := range fld.Names {
+			res.Fields = append(res.Fields, StructField{
+				Comment: cmt,
+				ID:      r.id(id),
+				Type:    typ,
+				Tags:    tags,
+			})
+		}
+	}
+
+	return res
+}
+
+func (r *astReader) tags(lit *ast.BasicLit) Tags {
+	if lit == nil {
+		return nil
+	}
+
+	str, err := strconv.Unquote(lit.Value)
+	if err != nil {
+		r.fail(lit, "invalid struct tag %s", lit.Value)
+		return nil
+	}
+
+	res, ok := parseTags(str)
+	if !ok {
+		r.fail(lit, "struct tag %s cannot be represented", lit.Value)
+		return nil
+	}
+
+	return res
+}
+
+// parseTags reads the struct tag str, in the conventional format parsed by reflect.StructTag.
+func parseTags(str string) (Tags, bool) {
+	var res Tags
+
+	for str = strings.TrimLeft(str, " "); str != ""; str = strings.TrimLeft(str, " ") {
+		idx := strings.IndexByte(str, ':')
+		if idx < 1 || strings.ContainsAny(str[:idx], " \"") {
+			break
+		}
+
+		quoted, err := strconv.QuotedPrefix(str[idx+1:])
+		if err != nil || quoted[0] != '"' {
+			break
+		}
+
+		val, _ := strconv.Unquote(quoted)
+		res = append(res, Tag{Name: str[:idx], Value: val})
+		str = str[idx+1+len(quoted):]
+	}
+
+	return res, str == ""
+}
+
+func (r *astReader) optExpr(expr ast.Expr) Expr {
+	if expr == nil {
+		return nil
+	}
+
+	return r.expr(expr)
+}
+
+func (r *astReader) exprs(list []ast.Expr) Exprs {
+	res := make(Exprs, 0, len(list))
+
+	for _, itm := range list {
+		if expr := r.expr(itm); expr != nil {
+			res = append(res, expr)
+		}
+	}
+
+	return res
+}
+
+func (r *astReader) expr(expr ast.Expr) Expr {
+	switch expr := expr.(type) {
+	case *ast.BasicLit:
+		return r.basicLit(expr)
+	case *ast.Ident:
+		if arg, ok := r.placeholder(expr); ok {
+			return r.exprArg(expr, arg)
+		}
+
+		switch expr.Name {
+		case "nil":
+			return Nil
+		case "iota":
+			return Iota
+		case "true":
+			return True
+		case "false":
+			return False
+		}
+
+		return Symbol{ID: ID(expr.Name)}
+	case *ast.SelectorExpr:
+		if sym, ok := r.qualified(expr); ok {
+			return sym
+		}
+
+		return MemberExpr{Value: r.expr(expr.X), ID: r.id(expr.Sel)}
+	case *ast.ParenExpr:
+		return ParExpr{Expr: r.expr(expr.X)}
+	case *ast.CompositeLit:
+		return r.compositeLit(expr)
+	case *ast.FuncLit:
+		return FuncExpr{
+			Params: r.params(expr.Type.Params),
+			Return: r.params(expr.Type.Results),
+			Body:   r.block(expr.Body),
+		}
+	case *ast.CallExpr:
+		return r.callExpr(expr)
+	case *ast.TypeAssertExpr:
+		if expr.Type == nil {
+			r.fail(expr, "type switch guard outside of a type switch")
+			return nil
+		}
+
+		return TypeAssertExpr{Value: r.expr(expr.X), Type: r.typ(expr.Type)}
+	case *ast.IndexExpr:
+		return IndexExpr{Slice: r.expr(expr.X), Index: r.expr(expr.Index)}
+	case *ast.IndexListExpr:
+		if sym, ok := r.genericType(expr, expr.X, expr.Indices).(Symbol); ok {
+			return sym
+		}
+
+		return nil
+	case *ast.SliceExpr:
+		if expr.Slice3 {
+			r.fail(expr, "full slice expressions cannot be represented")
+			return nil
+		}
+
+		return RangeExpr{Slice: r.expr(expr.X), Min: r.optExpr(expr.Low), Max: r.optExpr(expr.High)}
+	case *ast.StarExpr:
+		return DerefExpr{Op: r.expr(expr.X)}
+	case *ast.UnaryExpr:
+		return r.unaryExpr(expr)
+	case *ast.BinaryExpr:
+		return r.binaryExpr(expr)
+	}
+
+	r.fail(expr, "unsupported expression %T", expr)
+	return nil
+}
+
+func (r *astReader) basicLit(lit *ast.BasicLit) Expr {
+	switch lit.Kind {
+	case token.INT:
+		var val Expr
+
+		if v, err := strconv.ParseInt(lit.Value, 0, 64); err == nil {
+			val = IntExpr(v)
+		} else if v, err := strconv.ParseUint(lit.Value, 0, 64); err == nil {
+			val = UintExpr(v)
+		} else {
+			break
+		}
+
+		if f, digits := intFormat(lit.Value); f != Decimal {
+			return IntFormatExpr{Value: val, Format: f, Digits: digits}
+		}
+
+		return val
+	case token.FLOAT:
+		if val, err := strconv.ParseFloat(lit.Value, 64); err == nil {
+			if strings.ContainsAny(lit.Value, "eE") && !strings.HasPrefix(lit.Value, "0x") && !strings.HasPrefix(lit.Value, "0X") {
+				return ExpFloatExpr(val)
+			}
+
+			return FloatExpr(val)
+		}
+	case token.CHAR:
+		if val, _, tail, err := strconv.UnquoteChar(lit.Value[1:len(lit.Value)-1], '\''); err == nil && tail == "" {
+			return RuneExpr(val)
+		}
+	case token.STRING:
+		if val, err := strconv.Unquote(lit.Value); err == nil {
+			if lit.Value[0] == '`' {
+				return RawStringExpr(val)
+			}
+
+			return StringExpr(val)
+		}
+	}
+
+	r.fail(lit, "literal %s cannot be represented", lit.Value)
+	return nil
+}
+
+// intFormat returns the format of the integer literal lit, and its number of digits.
+func intFormat(lit string) (IntFormat, int) {
+	lit = strings.ReplaceAll(lit, "_", "")
+
+	if len(lit) < 2 || lit[0] != '0' {
+		return Decimal, 0
+	}
+
+	switch lit[1] {
+	case 'x', 'X':
+		return Hexadecimal, len(lit) - 2
+	case 'o', 'O':
+		return Octal, len(lit) - 2
+	case 'b', 'B':
+		return Binary, len(lit) - 2
+	}
+
+	return Octal, len(lit) - 1
+}
+
+// bytesLit returns the BytesExpr holding items, if they are all bytes written as two hexadecimal digits.
+func bytesLit(items Exprs) (BytesExpr, bool) {
+	res := make(BytesExpr, len(items))
+
+	for idx, itm := range items {
+		lit, ok := itm.(IntFormatExpr)
+		if !ok || lit.Format != Hexadecimal || lit.Digits != 2 {
+			return nil, false
+		}
+
+		val, ok := lit.Value.(IntExpr)
+		if !ok || val < 0 || val > 0xff {
+			return nil, false
+		}
+
+		res[idx] = byte(val)
+	}
+
+	return res, true
+}
+
+func isByteSlice(t Type) bool {
+	slice, ok := t.(SliceType)
+	if !ok || slice.Size != nil {
+		return false
+	}
+
+	sym, ok := slice.Items.(Symbol)
+	return ok && sym.ID == Byte.ID && sym.Package == nil && sym.Path == "" && len(sym.GenArgs) < 1
+}
+
+func (r *astReader) compositeLit(lit *ast.CompositeLit) Expr {
+	var typ Type
+
+	if lit.Type != nil {
+		typ = r.typ(lit.Type)
+	}
+
+	keyed := len(lit.Elts) > 0
+
+	for _, itm := range lit.Elts {
+		if _, ok := itm.(*ast.KeyValueExpr); !ok {
+			keyed = false
+		}
+	}
+
+	switch lit.Type.(type) {
+	case *ast.ArrayType:
+		if keyed {
+			r.fail(lit, "indexed array and slice literals cannot be represented")
+			return nil
+		}
+
+		items := r.exprs(lit.Elts)
+
+		if isByteSlice(typ) && len(items) > 0 {
+			if res, ok := bytesLit(items); ok {
+				return res
+			}
+		}
+
+		return SliceExpr{Type: typ, Items: items}
+	case *ast.MapType:
+		return r.mapLit(lit, typ)
+	}
+
+	if !keyed {
+		if len(lit.Elts) < 1 {
+			return StructExpr{Type: typ}
+		}
+
+		return SliceExpr{Type: typ, Items: r.exprs(lit.Elts)}
+	}
+
+	fields := make([]StructExprField, 0, len(lit.Elts))
+
+	for _, itm := range lit.Elts {
+		kv := itm.(*ast.KeyValueExpr)
+
+		id, ok := kv.Key.(*ast.Ident)
+		if !ok {
+			// not a struct literal
+			return r.mapLit(lit, typ)
+		}
+
+		fields = append(fields, StructExprField{ID: r.id(id), Value: r.expr(kv.Value)})
+	}
+
+	return StructExpr{Type: typ, Fields: fields}
+}
+
+func (r *astReader) mapLit(lit *ast.CompositeLit, typ Type) Expr {
+	res := MapExpr{Type: typ}
+
+	for _, itm := range lit.Elts {
+		kv, ok := itm.(*ast.KeyValueExpr)
+		if !ok {
+			r.fail(itm, "map literal entry requires a key")
+			continue
+		}
+
+		res.Entries = append(res.Entries, MapEntry{Key: r.expr(kv.Key), Value: r.expr(kv.Value)})
+	}
+
+	return res
+}
+
+func (r *astReader) callExpr(call *ast.CallExpr) Expr {
+	spread := call.Ellipsis.IsValid()
+
+	if id, ok := call.Fun.(*ast.Ident); ok && len(call.Args) > 0 && !spread {
+		switch id.Name {
+		case "new":
+			if len(call.Args) == 1 {
+				return NewExpr{Type: r.typ(call.Args[0])}
+			}
+		case "make":
+			return MakeExpr{Type: r.typ(call.Args[0]), Sizes: r.exprs(call.Args[1:])}
+		}
+	}
+
+	if isTypeExpr(call.Fun) {
+		if len(call.Args) != 1 || spread {
+			r.fail(call, "conversion requires exactly one value")
+			return nil
+		}
+
+		return CastExpr{Type: r.typ(call.Fun), Value: r.expr(call.Args[0])}
+	}
+
+	// explicit instantiations with a single type argument are read as index expressions
+	if inst, ok := call.Fun.(*ast.IndexListExpr); ok {
+		res := CallExpr{Func: r.expr(inst.X), Args: r.exprs(call.Args), Spread: spread}
+
+		for _, itm := range inst.Indices {
+			res.GenArgs = append(res.GenArgs, r.typ(itm))
+		}
+
+		return res
+	}
+
+	return CallExpr{Func: r.expr(call.Fun), Args: r.exprs(call.Args), Spread: spread}
+}
+
+// isTypeExpr returns whether expr is syntactically a type that is not also an expression.
+func isTypeExpr(expr ast.Expr) bool {
+	switch expr := expr.(type) {
+	case *ast.ParenExpr:
+		return isTypeExpr(expr.X)
+	case *ast.StarExpr:
+		// (*T)(x) is a conversion; *p(x) is parsed as a dereference
+		return true
+	case *ast.ArrayType, *ast.MapType, *ast.ChanType, *ast.FuncType, *ast.InterfaceType, *ast.StructType:
+		return true
+	}
+
+	return false
+}
+
+func (r *astReader) unaryExpr(expr *ast.UnaryExpr) Expr {
+	op := r.expr(expr.X)
+
+	switch expr.Op {
+	case token.ADD:
+		return IdentExpr{Op: op}
+	case token.SUB:
+		return NegateExpr{Op: op}
+	case token.NOT:
+		return NotExpr{Op: op}
+	case token.XOR:
+		return ComplementExpr{Op: op}
+	case token.AND:
+		return AddrOfExpr{Op: op}
+	case token.ARROW:
+		return RecvExpr{Chan: op}
+	}
+
+	r.fail(expr, "unsupported unary operator %s", expr.Op)
+	return nil
+}
+
+func (r *astReader) binaryExpr(expr *ast.BinaryExpr) Expr {
+	lhs := r.expr(expr.X)
+	rhs := r.expr(expr.Y)
+
+	switch expr.Op {
+	case token.ADD:
+		return AddExpr{LHS: lhs, RHS: rhs}
+	case token.SUB:
+		return SubtractExpr{LHS: lhs, RHS: rhs}
+	case token.MUL:
+		return MultiplyExpr{LHS: lhs, RHS: rhs}
+	case token.QUO:
+		return DivideExpr{LHS: lhs, RHS: rhs}
+	case token.REM:
+		return ModulusExpr{LHS: lhs, RHS: rhs}
+	case token.SHL:
+		return ShiftLeftExpr{LHS: lhs, RHS: rhs}
+	case token.SHR:
+		return ShiftRightExpr{LHS: lhs, RHS: rhs}
+	case token.EQL:
+		return EqualExpr{LHS: lhs, RHS: rhs}
+	case token.NEQ:
+		return NotEqualExpr{LHS: lhs, RHS: rhs}
+	case token.LSS:
+		return LessThanExpr{LHS: lhs, RHS: rhs}
+	case token.LEQ:
+		return LessOrEqualExpr{LHS: lhs, RHS: rhs}
+	case token.GTR:
+		return MoreThanExpr{LHS: lhs, RHS: rhs}
+	case token.GEQ:
+		return MoreOrEqualExpr{LHS: lhs, RHS: rhs}
+	case token.AND:
+		return BitAndExpr{LHS: lhs, RHS: rhs}
+	case token.AND_NOT:
+		return BitClearExpr{LHS: lhs, RHS: rhs}
+	case token.XOR:
+		return BitXorExpr{LHS: lhs, RHS: rhs}
+	case token.OR:
+		return BitOrExpr{LHS: lhs, RHS: rhs}
+	case token.LAND:
+		return LogAndExpr{LHS: lhs, RHS: rhs}
+	case token.LOR:
+		return LogOrExpr{LHS: lhs, RHS: rhs}
+	}
+
+	r.fail(expr, "unsupported binary operator %s", expr.Op)
+	return nil
+}
+
+// stmtsIn converts the statements of a block spanning from to to, along with the regions delimiting them.
+func (r *astReader) stmtsIn(list []ast.Stmt, from token.Pos, to token.Pos) []Stmt {
+	marks := r.markersIn(list, from, to)
+	stack := []regionFrame[Stmt]{{}}
+	wrap := func(name string, items []Stmt) Stmt { return RegionStmt{Name: name, Stmts: items} }
+	next := 0
+
+	for _, itm := range list {
+		for ; next < len(marks) && marks[next].Pos() < itm.Pos(); next++ {
+			stack = readMarker(r, stack, marks[next], wrap)
+		}
+
+		if stmts, ok := r.stmtsArg(itm); ok {
+			top := &stack[len(stack)-1]
+			top.items = append(top.items, stmts...)
+		} else if stmt := r.stmt(itm); stmt != nil {
+			top := &stack[len(stack)-1]
+			top.items = append(top.items, stmt)
+		}
+	}
+
+	for ; next < len(marks); next++ {
+		stack = readMarker(r, stack, marks[next], wrap)
+	}
+
+	return closeRegions(r, stack, wrap)
+}
+
+// markersIn returns the region markers located between from and to, outside of the statements of list.
+func (r *astReader) markersIn(list []ast.Stmt, from token.Pos, to token.Pos) []*ast.Comment {
+	var res []*ast.Comment
+
+	for _, grp := range r.groups {
+		if grp.Pos() <= from || grp.End() > to {
+			continue
+		}
+
+	comments:
+		for _, itm := range grp.List {
+			if !r.isMarker(itm) {
+				continue
+			}
+
+			for _, stmt := range list {
+				if stmt.Pos() <= itm.Pos() && itm.End() <= stmt.End() {
+					continue comments
+				}
+			}
+
+			res = append(res, itm)
+		}
+	}
+
+	return res
+}
+
+func (r *astReader) block(block *ast.BlockStmt) BlockStmt {
+	if block == nil {
+		return nil
+	}
+
+	return r.stmtsIn(block.List, block.Lbrace, block.Rbrace)
+}
+
+func (r *astReader) hasMarker(grp *ast.CommentGroup) bool {
+	for _, itm := range grp.List {
+		if r.isMarker(itm) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r *astReader) isMarker(c *ast.Comment) bool {
+	_, _, ok := r.parseMarker(c.Text)
+	return ok
+}
+
+// parseMarker parses a region marker written with the markers of the reader.
+func (r *astReader) parseMarker(text string) (name string, begin bool, ok bool) {
+	if name, ok := strings.CutPrefix(text, strings.TrimSpace(r.markers.Begin)); ok {
+		return strings.TrimSpace(name), true, true
+	}
+
+	if name, ok := strings.CutPrefix(text, strings.TrimSpace(r.markers.End)); ok {
+		return strings.TrimSpace(name), false, true
+	}
+
+	return "", false, false
+}
+
+// readMarker opens or closes a region; closed regions are wrapped into a single item of their parent.
+func readMarker[T any](r *astReader, stack []regionFrame[T], c *ast.Comment, wrap func(string, []T) T) []regionFrame[T] {
+	name, begin, _ := r.parseMarker(c.Text)
+	r.comments[c] = true
+
+	if begin {
+		return append(stack, regionFrame[T]{name: name, begin: c})
+	}
+
+	top := stack[len(stack)-1]
+
+	if len(stack) < 2 || top.name != name {
+		r.fail(c, "region '%s' ends without beginning", name)
+		return stack
+	}
+
+	stack = stack[:len(stack)-1]
+	parent := &stack[len(stack)-1]
+	parent.items = append(parent.items, wrap(name, top.items))
+
+	return stack
+}
+
+func closeRegions[T any](r *astReader, stack []regionFrame[T], wrap func(string, []T) T) []T {
+	for len(stack) > 1 {
+		top := stack[len(stack)-1]
+		r.fail(top.begin, "region '%s' does not end", top.name)
+
+		stack = stack[:len(stack)-1]
+		parent := &stack[len(stack)-1]
+		parent.items = append(parent.items, wrap(top.name, top.items))
+	}
+
+	return stack[0].items
+}
+
+func (r *astReader) initStmt(stmt ast.Stmt) InitStmt {
+	if stmt == nil {
+		return nil
+	}
+
+	res, ok := r.stmt(stmt).(InitStmt)
+	if !ok {
+		r.fail(stmt, "unsupported simple statement %T", stmt)
+		return nil
+	}
+
+	return res
+}
+
+func (r *astReader) postStmt(stmt ast.Stmt) PostStmt {
+	if stmt == nil {
+		return nil
+	}
+
+	res, ok := r.stmt(stmt).(PostStmt)
+	if !ok {
+		r.fail(stmt, "unsupported simple statement %T", stmt)
+		return nil
+	}
+
+	return res
+}
+
+func (r *astReader) stmt(stmt ast.Stmt) Stmt {
+	switch stmt := stmt.(type) {
+	case *ast.EmptyStmt:
+		return nil
+	case *ast.AssignStmt:
+		res := AssignStmt{Dests: r.exprs(stmt.Lhs), Srcs: r.exprs(stmt.Rhs)}
+
+		switch stmt.Tok {
+		case token.DEFINE:
+			res.Auto = true
+		case token.ASSIGN:
+		default:
+			res.Op = AssignOp(stmt.Tok.String())
+		}
+
+		return res
+	case *ast.BlockStmt:
+		return r.block(stmt)
+	case *ast.BranchStmt:
+		var label ID
+		if stmt.Label != nil {
+			label = ID(stmt.Label.Name)
+		}
+
+		switch stmt.Tok {
+		case token.BREAK:
+			return BreakStmt{Label: label}
+		case token.CONTINUE:
+			return ContinueStmt{Label: label}
+		case token.GOTO:
+			return GotoStmt{Label: label}
+		case token.FALLTHROUGH:
+			return FallThroughStmt{}
+		}
+	case *ast.DeclStmt:
+		return r.declStmt(stmt)
+	case *ast.DeferStmt:
+		return DeferStmt{Expr: r.expr(stmt.Call)}
+	case *ast.ExprStmt:
+		if id, ok := stmt.X.(*ast.Ident); ok {
+			if arg, ok := r.placeholder(id); ok {
+				return r.stmtArg(id, arg)
+			}
+		}
+
+		return ExprStmt{Expr: r.expr(stmt.X)}
+	case *ast.ForStmt:
+		return ForStmt{
+			Init: r.initStmt(stmt.Init),
+			Cond: r.optExpr(stmt.Cond),
+			Next: r.postStmt(stmt.Post),
+			Then: r.block(stmt.Body),
+		}
+	case *ast.GoStmt:
+		return GoStmt{Expr: r.expr(stmt.Call)}
+	case *ast.IfStmt:
+		return r.ifStmt(stmt)
+	case *ast.IncDecStmt:
+		return IncDecStmt{Expr: r.expr(stmt.X), Dec: stmt.Tok == token.DEC}
+	case *ast.LabeledStmt:
+		return LabeledStmt{Label: ID(stmt.Label.Name), Stmt: r.stmt(stmt.Stmt)}
+	case *ast.RangeStmt:
+		return RangeStmt{
+			Auto:  stmt.Tok == token.DEFINE,
+			Key:   r.optExpr(stmt.Key),
+			Value: r.optExpr(stmt.Value),
+			Range: r.expr(stmt.X),
+			Then:  r.block(stmt.Body),
+		}
+	case *ast.ReturnStmt:
+		switch len(stmt.Results) {
+		case 0:
+			return ReturnStmt{}
+		case 1:
+			return ReturnStmt{Value: r.expr(stmt.Results[0])}
+		}
+
+		return ReturnStmt{Values: r.exprs(stmt.Results)}
+	case *ast.SelectStmt:
+		return r.selectStmt(stmt)
+	case *ast.SendStmt:
+		return SendStmt{Chan: r.expr(stmt.Chan), Value: r.expr(stmt.Value)}
+	case *ast.SwitchStmt:
+		return r.switchStmt(stmt)
+	case *ast.TypeSwitchStmt:
+		return r.typeSwitchStmt(stmt)
+	}
+
+	r.fail(stmt, "unsupported statement %T", stmt)
+	return nil
+}
+
+func (r *astReader) declStmt(stmt *ast.DeclStmt) Stmt {
+	decl, ok := stmt.Decl.(*ast.GenDecl)
+	if !ok {
+		r.fail(stmt, "unsupported declaration statement")
+		return nil
+	}
+
+	single := !decl.Lparen.IsValid()
+
+	switch decl.Tok {
+	case token.CONST:
+		items := r.constSpecs(decl)
+		if single {
+			return items[0]
+		}
+
+		return ConstDecls(items)
+	case token.TYPE:
+		items := r.typeSpecs(decl)
+		if single {
+			return items[0]
+		}
+
+		return TypeDecls(items)
+	case token.VAR:
+		items := r.varSpecs(decl)
+		if single {
+			return items[0]
+		}
+
+		return VarDecls(items)
+	}
+
+	r.fail(stmt, "unsupported declaration statement")
+	return nil
+}
+
+func (r *astReader) ifStmt(stmt *ast.IfStmt) Stmt {
+	res := IfStmt{
+		Init: r.initStmt(stmt.Init),
+		Cond: r.expr(stmt.Cond),
+		Then: r.block(stmt.Body),
+	}
+
+	switch els := stmt.Else.(type) {
+	case *ast.IfStmt:
+		res.Else = r.ifStmt(els).(ElseStmt)
+	case *ast.BlockStmt:
+		res.Else = r.block(els)
+	}
+
+	return res
+}
+
+func (r *astReader) selectStmt(stmt *ast.SelectStmt) Stmt {
+	res := SelectStmt{}
+
+	for _, itm := range stmt.Body.List {
+		cas := itm.(*ast.CommClause)
+		res.Cases = append(res.Cases, SelectCase{Comm: r.optStmt(cas.Comm), Stmts: r.stmtsIn(cas.Body, cas.Colon, cas.End())})
+	}
+
+	return res
+}
+
+func (r *astReader) optStmt(stmt ast.Stmt) Stmt {
+	if stmt == nil {
+		return nil
+	}
+
+	return r.stmt(stmt)
+}
+
+func (r *astReader) switchStmt(stmt *ast.SwitchStmt) Stmt {
+	res := SwitchStmt{
+		Init:  r.initStmt(stmt.Init),
+		Value: r.optExpr(stmt.Tag),
+	}
+
+	for _, itm := range stmt.Body.List {
+		cas := itm.(*ast.CaseClause)
+		out := SwitchCase{Stmts: r.stmtsIn(cas.Body, cas.Colon, cas.End())}
+
+		switch len(cas.List) {
+		case 0:
+		case 1:
+			out.Value = r.expr(cas.List[0])
+		default:
+			r.fail(cas, "switch cases with several values cannot be represented")
+			continue
+		}
+
+		res.Cases = append(res.Cases, out)
+	}
+
+	return res
+}
+
+func (r *astReader) typeSwitchStmt(stmt *ast.TypeSwitchStmt) Stmt {
+	res := TypeSwitchStmt{Init: r.initStmt(stmt.Init)}
+
+	var guard ast.Expr
+
+	switch assign := stmt.Assign.(type) {
+	case *ast.AssignStmt:
+		res.Bind = r.id(assign.Lhs[0].(*ast.Ident))
+		guard = assign.Rhs[0]
+	case *ast.ExprStmt:
+		guard = assign.X
+	}
+
+	res.Value = r.expr(guard.(*ast.TypeAssertExpr).X)
+
+	for _, itm := range stmt.Body.List {
+		cas := itm.(*ast.CaseClause)
+		out := TypeSwitchCase{Stmts: r.stmtsIn(cas.Body, cas.Colon, cas.End())}
+
+		for _, typ := range cas.List {
+			out.Types = append(out.Types, r.typ(typ))
+		}
+
+		res.Cases = append(res.Cases, out)
+	}
+
+	return res
+}
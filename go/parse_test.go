@@ -0,0 +1,109 @@
+package golang_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	code "github.com/trwk76/go-code"
+	golang "github.com/trwk76/go-code/go"
+)
+
+func TestParseFile(t *testing.T) {
+	for _, item := range testItems {
+		unit, err := golang.ParseFile(item.name+".go", item.text)
+		if err != nil {
+			t.Errorf("test '%s' failed: %s", item.name, err.Error())
+			continue
+		}
+
+		res, err := code.WriteStringErr("\t", func(w *code.Writer) { unit.Write(w) })
+		if err != nil {
+			t.Errorf("test '%s' failed: %s", item.name, err.Error())
+			continue
+		}
+
+		if res != item.text {
+			t.Errorf("test '%s' does not round-trip:\n%s", item.name, res)
+		}
+	}
+
+	src := `package my
+
+func Join(s []string) string {
+	return concat(s[0:1:2])
+}
+`
+
+	unit, err := golang.ParseFile("my.go", src)
+
+	var errs golang.ParseErrors
+
+	if !errors.As(err, &errs) || len(errs) != 1 {
+		t.Fatalf("expected a single parse error; got: %v", err)
+	}
+
+	if errs[0].Pos.Line != 4 {
+		t.Errorf("parse error reported at wrong position: %s", errs[0].Error())
+	}
+
+	if len(unit.Decls) != 1 {
+		t.Errorf("expected the function to be kept; got %d declarations", len(unit.Decls))
+	}
+}
+
+func TestParseMarkers(t *testing.T) {
+	markers := code.Markers{Begin: "// <<< ", End: "// >>> "}
+	src := strings.NewReplacer(code.DefaultMarkers.Begin, markers.Begin, code.DefaultMarkers.End, markers.End).Replace(regionsText)
+
+	unit, err := golang.ParseFileMarkers("regions.go", src, markers)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res := code.WriteString("\t", func(w *code.Writer) {
+		w.SetMarkers(markers)
+
+		if err := unit.Write(w); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	if res != src {
+		t.Errorf("regions do not round-trip:\n%s", res)
+	}
+}
+
+func TestParseBuild(t *testing.T) {
+	_, err := golang.ParseFile("my.go", "//go:build linux &&\n\npackage my\n")
+
+	var errs golang.ParseErrors
+
+	if !errors.As(err, &errs) || len(errs) != 1 {
+		t.Fatalf("expected a single parse error; got: %v", err)
+	}
+
+	if errs[0].Pos.Line != 1 {
+		t.Errorf("parse error reported at wrong position: %s", errs[0].Error())
+	}
+}
+
+func TestParseExpr(t *testing.T) {
+	expr, err := golang.ParseExpr("a + b*c")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := expr.(golang.AddExpr); !ok {
+		t.Errorf("expected an addition; got %T", expr)
+	}
+
+	stmts, err := golang.ParseStmts("x := 1\nx++")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(stmts) != 2 {
+		t.Errorf("expected 2 statements; got %d", len(stmts))
+	}
+}
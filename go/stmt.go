@@ -2,10 +2,25 @@ package golang
 
 import (
 	"errors"
+	"fmt"
 
 	code "github.com/trwk76/go-code"
 )
 
+const (
+	AssignAdd    AssignOp = "+="
+	AssignSub    AssignOp = "-="
+	AssignMul    AssignOp = "*="
+	AssignDiv    AssignOp = "/="
+	AssignMod    AssignOp = "%="
+	AssignAnd    AssignOp = "&="
+	AssignOr     AssignOp = "|="
+	AssignXor    AssignOp = "^="
+	AssignShl    AssignOp = "<<="
+	AssignShr    AssignOp = ">>="
+	AssignAndNot AssignOp = "&^="
+)
+
 type (
 	Stmt interface {
 		simpleStmt() bool
@@ -22,16 +37,32 @@ type (
 		initStmt()
 	}
 
+	// PostStmt is a statement allowed after the condition of a for statement.
+	PostStmt interface {
+		InitStmt
+		postStmt()
+	}
+
+	// AssignStmt writes Dests = Srcs, Dests := Srcs when Auto is set, or Dests <Op> Srcs when Op is set.
 	AssignStmt struct {
 		Auto  bool
+		Op    AssignOp
 		Dests Exprs
 		Srcs  Exprs
 	}
 
+	// AssignOp is a compound assignment operator (+=, <<=, ...).
+	AssignOp string
+
 	BlockStmt []Stmt
 
-	BreakStmt    struct{}
-	ContinueStmt struct{}
+	BreakStmt struct {
+		Label ID
+	}
+
+	ContinueStmt struct {
+		Label ID
+	}
 
 	DeferStmt struct {
 		Expr Expr
@@ -46,10 +77,18 @@ type (
 	ForStmt struct {
 		Init InitStmt
 		Cond Expr
-		Next *AssignStmt
+		Next PostStmt
 		Then BlockStmt
 	}
 
+	GoStmt struct {
+		Expr Expr
+	}
+
+	GotoStmt struct {
+		Label ID
+	}
+
 	IfStmt struct {
 		Init InitStmt
 		Cond Expr
@@ -57,11 +96,54 @@ type (
 		Else ElseStmt
 	}
 
+	IncDecStmt struct {
+		Expr Expr
+		Dec  bool
+	}
+
+	LabeledStmt struct {
+		Label ID
+		Stmt  Stmt
+	}
+
+	RangeStmt struct {
+		Auto  bool
+		Key   Expr
+		Value Expr
+		Range Expr
+		Then  BlockStmt
+	}
+
+	// RegionStmt writes Stmts within a region named Name (see RegionDecl).
+	RegionStmt struct {
+		Name  string
+		Stmts []Stmt
+	}
+
 	ReturnStmt struct {
 		Value Expr
+		// Values lists the values of a multi-value return; it is used instead of Value when not empty.
+		Values Exprs
+	}
+
+	SelectStmt struct {
+		Cases []SelectCase
+	}
+
+	// SelectCase is a case of a select statement; a nil Comm denotes the default case.
+	// Comm is expected to be a SendStmt, or an ExprStmt or AssignStmt receiving from a channel.
+	SelectCase struct {
+		Comm  Stmt
+		Stmts []Stmt
+	}
+
+	SendStmt struct {
+		Chan  Expr
+		Value Expr
 	}
 
 	SwitchStmt struct {
+		Init  InitStmt
 		Value Expr
 		Cases []SwitchCase
 	}
@@ -70,9 +152,26 @@ type (
 		Value Expr
 		Stmts []Stmt
 	}
+
+	// TypeSwitchStmt is a switch on the dynamic type of Value; Bind optionally names the variable
+	// holding the value converted to the type of each case.
+	TypeSwitchStmt struct {
+		Init  InitStmt
+		Bind  ID
+		Value Expr
+		Cases []TypeSwitchCase
+	}
+
+	// TypeSwitchCase is a case of a type switch; empty Types denote the default case.
+	// Nil can be listed to match a nil interface value.
+	TypeSwitchCase struct {
+		Types []Type
+		Stmts []Stmt
+	}
 )
 
 func (s AssignStmt) initStmt() {}
+func (s AssignStmt) postStmt() {}
 
 func (s AssignStmt) simpleStmt() bool {
 	return s.Dests.simpleExprs() && s.Srcs.simpleExprs()
@@ -81,13 +180,32 @@ func (s AssignStmt) simpleStmt() bool {
 func (s AssignStmt) writeStmt(w *code.Writer, singleLine bool) {
 	s.Dests.writeExprs(w, true)
 	w.Space()
-	if s.Auto {
+	if s.Op != "" {
+		if s.Auto {
+			w.Fail(fmt.Errorf("assignment operator '%s' cannot be used with automatic declaration", s.Op))
+		} else if len(s.Dests) != 1 || len(s.Srcs) != 1 {
+			w.Fail(fmt.Errorf("assignment operator '%s' requires exactly one destination and one source", s.Op))
+		} else if err := s.Op.check(); err != nil {
+			w.Fail(err)
+		}
+
+		w.WriteString(string(s.Op))
+	} else if s.Auto {
 		w.WriteString(":=")
 	} else {
 		w.WriteString("=")
 	}
 	w.Space()
-	s.Srcs.writeExprs(w, singleLine)
+	writeValues(w, s.Srcs, singleLine)
+}
+
+func (o AssignOp) check() error {
+	switch o {
+	case AssignAdd, AssignSub, AssignMul, AssignDiv, AssignMod, AssignAnd, AssignOr, AssignXor, AssignShl, AssignShr, AssignAndNot:
+		return nil
+	}
+
+	return fmt.Errorf("'%s' is not a valid assignment operator", o)
 }
 
 func (s BlockStmt) elseStmt() {}
@@ -145,16 +263,18 @@ func (BreakStmt) simpleStmt() bool {
 	return true
 }
 
-func (BreakStmt) writeStmt(w *code.Writer, singleLine bool) {
+func (s BreakStmt) writeStmt(w *code.Writer, singleLine bool) {
 	w.WriteString("break")
+	writeLabel(w, s.Label)
 }
 
 func (ContinueStmt) simpleStmt() bool {
 	return true
 }
 
-func (ContinueStmt) writeStmt(w *code.Writer, singleLine bool) {
+func (s ContinueStmt) writeStmt(w *code.Writer, singleLine bool) {
 	w.WriteString("continue")
+	writeLabel(w, s.Label)
 }
 
 func (s DeferStmt) simpleStmt() bool {
@@ -166,6 +286,9 @@ func (s DeferStmt) writeStmt(w *code.Writer, singleLine bool) {
 	writeExpr(w, s.Expr, singleLine, "defer statement requires an expression")
 }
 
+func (s ExprStmt) initStmt() {}
+func (s ExprStmt) postStmt() {}
+
 func (s ExprStmt) simpleStmt() bool {
 	return s.Expr == nil || s.Expr.simpleExpr()
 }
@@ -191,27 +314,56 @@ func (s ForStmt) simpleStmt() bool {
 
 func (s ForStmt) writeStmt(w *code.Writer, singleLine bool) {
 	w.WriteString("for ")
+	writeHeader(w, func(w *code.Writer) {
+		if s.Init != nil || s.Next != nil {
+			writeStmt(w, s.Init, singleLine, "")
+			w.WriteString("; ")
+			writeExpr(w, s.Cond, singleLine, "")
+			w.WriteString("; ")
 
-	if s.Init != nil || s.Next != nil {
-		writeStmt(w, s.Init, singleLine, "")
-		w.WriteString("; ")
-		writeExpr(w, s.Cond, singleLine, "")
-		w.WriteString("; ")
+			if s.Next != nil {
+				if assign, ok := s.Next.(AssignStmt); ok && assign.Auto {
+					w.Fail(errors.New("for statement cannot declare variables after its condition"))
+					return
+				}
 
-		if s.Next != nil {
-			writeStmt(w, s.Next, singleLine, "")
-			w.Space()
-		}
-	} else {
-		if s.Cond != nil {
-			writeExpr(w, s.Cond, singleLine, "")
-			w.Space()
+				writeStmt(w, s.Next, singleLine, "")
+				w.Space()
+			}
+		} else {
+			if s.Cond != nil {
+				writeExpr(w, s.Cond, singleLine, "")
+				w.Space()
+			}
 		}
-	}
+	})
 
 	s.Then.writeStmt(w, singleLine)
 }
 
+func (s GoStmt) simpleStmt() bool {
+	return s.Expr == nil || s.Expr.simpleExpr()
+}
+
+func (s GoStmt) writeStmt(w *code.Writer, singleLine bool) {
+	w.WriteString("go ")
+	writeExpr(w, s.Expr, singleLine, "go statement requires an expression")
+}
+
+func (GotoStmt) simpleStmt() bool {
+	return true
+}
+
+func (s GotoStmt) writeStmt(w *code.Writer, singleLine bool) {
+	if s.Label == "" {
+		w.Fail(errors.New("goto statement requires a label"))
+		return
+	}
+
+	w.WriteString("goto")
+	writeLabel(w, s.Label)
+}
+
 func (s IfStmt) elseStmt() {}
 
 func (s IfStmt) simpleStmt() bool {
@@ -222,13 +374,14 @@ func (s IfStmt) simpleStmt() bool {
 
 func (s IfStmt) writeStmt(w *code.Writer, singleLine bool) {
 	w.WriteString("if ")
+	writeHeader(w, func(w *code.Writer) {
+		if s.Init != nil {
+			s.Init.writeStmt(w, true)
+			w.WriteString("; ")
+		}
 
-	if s.Init != nil {
-		s.Init.writeStmt(w, true)
-		w.WriteString("; ")
-	}
-
-	writeExpr(w, s.Cond, true, "if statement requires a condition")
+		writeExpr(w, s.Cond, true, "if statement requires a condition")
+	})
 	w.Space()
 
 	s.Then.writeStmt(w, singleLine)
@@ -239,17 +392,155 @@ func (s IfStmt) writeStmt(w *code.Writer, singleLine bool) {
 	}
 }
 
+func (s IncDecStmt) initStmt() {}
+func (s IncDecStmt) postStmt() {}
+
+func (s IncDecStmt) simpleStmt() bool {
+	return s.Expr == nil || s.Expr.simpleExpr()
+}
+
+func (s IncDecStmt) writeStmt(w *code.Writer, singleLine bool) {
+	writeExpr(w, s.Expr, true, "increment/decrement statement requires an expression")
+
+	if s.Dec {
+		w.WriteString("--")
+	} else {
+		w.WriteString("++")
+	}
+}
+
+func (s LabeledStmt) simpleStmt() bool {
+	return false
+}
+
+func (s LabeledStmt) writeStmt(w *code.Writer, singleLine bool) {
+	w.Outdent(func(w *code.Writer) {
+		s.Label.write(w)
+		w.WriteByte(':')
+	})
+
+	if s.Stmt != nil {
+		w.Newline()
+		s.Stmt.writeStmt(w, singleLine)
+	}
+}
+
+func (s RangeStmt) simpleStmt() bool {
+	return (s.Key == nil || s.Key.simpleExpr()) &&
+		(s.Value == nil || s.Value.simpleExpr()) &&
+		(s.Range == nil || s.Range.simpleExpr()) &&
+		s.Then.simpleStmt()
+}
+
+func (s RangeStmt) writeStmt(w *code.Writer, singleLine bool) {
+	w.WriteString("for ")
+
+	if s.Key != nil || s.Value != nil {
+		if s.Key != nil {
+			s.Key.writeExpr(w, true)
+		} else {
+			Ignore.write(w)
+		}
+
+		if s.Value != nil {
+			w.WriteString(", ")
+			s.Value.writeExpr(w, true)
+		}
+
+		if s.Auto {
+			w.WriteString(" := ")
+		} else {
+			w.WriteString(" = ")
+		}
+	}
+
+	w.WriteString("range ")
+	writeHeader(w, func(w *code.Writer) {
+		writeExpr(w, s.Range, true, "range statement requires a range expression")
+	})
+	w.Space()
+
+	s.Then.writeStmt(w, singleLine)
+}
+
 func (s ReturnStmt) simpleStmt() bool {
-	return s.Value == nil || s.Value.simpleExpr()
+	return s.values().simpleExprs()
 }
 
 func (s ReturnStmt) writeStmt(w *code.Writer, singleLine bool) {
 	w.WriteString("return")
 
-	if s.Value != nil {
+	if vals := s.values(); len(vals) > 0 {
 		w.Space()
-		writeExpr(w, s.Value, singleLine, "")
+		writeValues(w, vals, singleLine)
+	}
+}
+
+func (s ReturnStmt) values() Exprs {
+	if len(s.Values) > 0 {
+		return s.Values
+	} else if s.Value != nil {
+		return Exprs{s.Value}
+	}
+
+	return nil
+}
+
+func (s RegionStmt) simpleStmt() bool {
+	return false
+}
+
+func (s RegionStmt) writeStmt(w *code.Writer, singleLine bool) {
+	w.Region(s.Name, func(w *code.Writer) {
+		for _, itm := range s.Stmts {
+			writeStmt(w, itm, false, "")
+			w.Newline()
+		}
+	})
+}
+
+func (s SelectStmt) simpleStmt() bool {
+	return false
+}
+
+func (s SelectStmt) writeStmt(w *code.Writer, singleLine bool) {
+	w.WriteString("select {")
+	w.Newline()
+
+	for _, cas := range s.Cases {
+		if cas.Comm != nil {
+			w.WriteString("case ")
+			cas.Comm.writeStmt(w, true)
+			w.WriteByte(':')
+		} else {
+			w.WriteString("default:")
+		}
+
+		w.Indent(func(w *code.Writer) {
+			for _, stmt := range cas.Stmts {
+				w.Newline()
+				writeStmt(w, stmt, false, "")
+			}
+		})
+
+		w.Newline()
 	}
+
+	w.WriteByte('}')
+}
+
+func (s SendStmt) initStmt() {}
+func (s SendStmt) postStmt() {}
+
+func (s SendStmt) simpleStmt() bool {
+	return (s.Chan == nil || s.Chan.simpleExpr()) &&
+		(s.Value == nil || s.Value.simpleExpr())
+}
+
+func (s SendStmt) writeStmt(w *code.Writer, singleLine bool) {
+	writeExpr(w, s.Chan, true, "send statement requires a channel expression")
+	w.WriteString(" <- ")
+	writeExpr(w, s.Value, singleLine, "send statement requires a value expression")
 }
 
 func (s SwitchStmt) simpleStmt() bool {
@@ -258,8 +549,19 @@ func (s SwitchStmt) simpleStmt() bool {
 
 func (s SwitchStmt) writeStmt(w *code.Writer, singleLine bool) {
 	w.WriteString("switch ")
-	writeExpr(w, s.Value, singleLine, "switch statement requires an expression")
-	w.WriteString(" {")
+	writeHeader(w, func(w *code.Writer) {
+		if s.Init != nil {
+			s.Init.writeStmt(w, true)
+			w.WriteString("; ")
+		}
+
+		if s.Value != nil {
+			s.Value.writeExpr(w, true)
+			w.Space()
+		}
+	})
+
+	w.WriteByte('{')
 
 	if singleLine {
 		for _, cas := range s.Cases {
@@ -296,7 +598,61 @@ func (s SwitchStmt) writeStmt(w *code.Writer, singleLine bool) {
 					writeStmt(w, stmt, singleLine, "")
 				}
 			})
+
+			w.Newline()
+		}
+	}
+
+	w.WriteByte('}')
+}
+
+func (s TypeSwitchStmt) simpleStmt() bool {
+	return false
+}
+
+func (s TypeSwitchStmt) writeStmt(w *code.Writer, singleLine bool) {
+	w.WriteString("switch ")
+	writeHeader(w, func(w *code.Writer) {
+		if s.Init != nil {
+			s.Init.writeStmt(w, true)
+			w.WriteString("; ")
+		}
+
+		if s.Bind != "" {
+			s.Bind.write(w)
+			w.WriteString(" := ")
+		}
+
+		writeExpr(w, s.Value, true, "type switch statement requires an expression")
+	})
+	w.WriteString(".(type) {")
+	w.Newline()
+
+	for _, cas := range s.Cases {
+		if len(cas.Types) > 0 {
+			w.WriteString("case ")
+
+			for idx, itm := range cas.Types {
+				if idx > 0 {
+					w.WriteString(", ")
+				}
+
+				writeType(w, itm, "type in type switch case must not be nil")
+			}
+
+			w.WriteByte(':')
+		} else {
+			w.WriteString("default:")
 		}
+
+		w.Indent(func(w *code.Writer) {
+			for _, stmt := range cas.Stmts {
+				w.Newline()
+				writeStmt(w, stmt, false, "")
+			}
+		})
+
+		w.Newline()
 	}
 
 	w.WriteByte('}')
@@ -349,17 +705,7 @@ func (d VarDecl) simpleStmt() bool {
 }
 
 func (d VarDecl) writeStmt(w *code.Writer, singleLine bool) {
-	d.Comment.write(w)
-
-	w.WriteString("var ")
-	d.ID.write(w)
-	w.Space()
-	d.Type.writeType(w)
-
-	if d.Value != nil {
-		w.WriteString(" = ")
-		d.Value.writeExpr(w, singleLine)
-	}
+	d.writeDeclItem(w, true)
 }
 
 func (d VarDecls) simpleStmt() bool {
@@ -370,14 +716,21 @@ func (d VarDecls) writeStmt(w *code.Writer, singleLine bool) {
 	d.writeDecl(w)
 }
 
-func stmtString(s Stmt, singleLine bool, reqMessage string) string {
-	return writeString(func(w *code.Writer) { writeStmt(w, s, singleLine, reqMessage) })
+func writeLabel(w *code.Writer, label ID) {
+	if label != "" {
+		w.Space()
+		label.write(w)
+	}
+}
+
+func stmtString(w *code.Writer, s Stmt, singleLine bool, reqMessage string) string {
+	return w.Capture(func(w *code.Writer) { writeStmt(w, s, singleLine, reqMessage) })
 }
 
 func writeStmt(w *code.Writer, s Stmt, singleLine bool, reqMessage string) {
 	if s == nil {
 		if reqMessage != "" {
-			panic(errors.New(reqMessage))
+			w.Fail(errors.New(reqMessage))
 		}
 
 		return
@@ -387,17 +740,26 @@ func writeStmt(w *code.Writer, s Stmt, singleLine bool, reqMessage string) {
 }
 
 var (
-	_ InitStmt = AssignStmt{}
+	_ PostStmt = AssignStmt{}
 	_ ElseStmt = BlockStmt{}
 	_ Stmt     = BreakStmt{}
 	_ Stmt     = ContinueStmt{}
 	_ Stmt     = DeferStmt{}
-	_ Stmt     = ExprStmt{}
+	_ PostStmt = ExprStmt{}
 	_ Stmt     = FallThroughStmt{}
 	_ Stmt     = ForStmt{}
+	_ Stmt     = GoStmt{}
+	_ Stmt     = GotoStmt{}
 	_ ElseStmt = IfStmt{}
+	_ PostStmt = IncDecStmt{}
+	_ Stmt     = LabeledStmt{}
+	_ Stmt     = RangeStmt{}
+	_ Stmt     = RegionStmt{}
 	_ Stmt     = ReturnStmt{}
+	_ Stmt     = SelectStmt{}
+	_ PostStmt = SendStmt{}
 	_ Stmt     = SwitchStmt{}
+	_ Stmt     = TypeSwitchStmt{}
 	_ Stmt     = ConstDecl{}
 	_ Stmt     = ConstDecls{}
 	_ Stmt     = FuncDecl{}
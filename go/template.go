@@ -0,0 +1,385 @@
+package golang
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// placeholderPrefix replaces the $ of the placeholders so that templates parse as Go source.
+const placeholderPrefix = "__tmpl_"
+
+type (
+	// Template is a Go snippet holding placeholders, written $name, parsed once and converted into
+	// nodes by Exec; the string and rune literals, and the comments, are kept as is. Depending on its position, a placeholder is replaced by an Expr,
+	// a Type, a Stmt or an ID; a placeholder standing for a whole statement may also be replaced by a
+	// []Stmt. A selector on a package imported by the template becomes a Symbol referencing the
+	// package by path, so that the unit using the nodes imports it. Any other selector must be on an
+	// identifier declared by the template or on a placeholder; a variable of the enclosing function,
+	// for instance, is written $req.Body.
+	Template[T any] struct {
+		fset   *token.FileSet
+		paths  map[string]string
+		params []string
+		read   func(r *astReader) T
+	}
+
+	// TemplateArgs are the values of the placeholders, by name.
+	TemplateArgs map[string]any
+)
+
+// ExprTemplate parses a Go expression template; imports are the paths of the packages it references.
+func ExprTemplate(src string, imports ...string) (*Template[Expr], error) {
+	fset := token.NewFileSet()
+
+	expr, err := parser.ParseExprFrom(fset, "", expandPlaceholders(src), 0)
+	if err != nil {
+		return nil, syntaxErrors(err)
+	}
+
+	return newTemplate(fset, expr, imports, func(r *astReader) Expr { return r.expr(expr) })
+}
+
+// StmtsTemplate parses a template of Go statements, as found in a function body; imports are the paths
+// of the packages it references.
+func StmtsTemplate(src string, imports ...string) (*Template[BlockStmt], error) {
+	const prefix = "package p; func _() {\n"
+
+	fset := token.NewFileSet()
+
+	file, err := parser.ParseFile(fset, "", prefix+expandPlaceholders(src)+"\n}", parser.ParseComments|parser.AllErrors)
+	if err != nil {
+		return nil, syntaxErrors(err)
+	}
+
+	return newTemplate(fset, file, imports, func(r *astReader) BlockStmt {
+		r.groups = file.Comments
+		res := r.block(file.Decls[0].(*ast.FuncDecl).Body)
+
+		for _, grp := range file.Comments {
+			r.failComment(grp)
+		}
+
+		return res
+	})
+}
+
+// DeclsTemplate parses a template of Go declarations, as found in a file after the package clause;
+// the packages it references are taken from its import declarations.
+func DeclsTemplate(src string) (*Template[Decls], error) {
+	const prefix = "package p\n"
+
+	fset := token.NewFileSet()
+
+	file, err := parser.ParseFile(fset, "", prefix+expandPlaceholders(src), parser.ParseComments|parser.AllErrors)
+	if err != nil {
+		return nil, syntaxErrors(err)
+	}
+
+	imports := make([]string, 0, len(file.Imports))
+
+	for _, imp := range file.Imports {
+		path, _ := strconv.Unquote(imp.Path.Value)
+
+		if imp.Name != nil {
+			return nil, ParseErrors{{Pos: fset.Position(imp.Pos()), Msg: "template imports cannot have an alias"}}
+		}
+
+		imports = append(imports, path)
+	}
+
+	return newTemplate(fset, file, imports, func(r *astReader) Decls {
+		r.groups = file.Comments
+
+		var res Decls
+
+		for _, decl := range file.Decls {
+			if gen, ok := decl.(*ast.GenDecl); ok && gen.Tok == token.IMPORT {
+				continue
+			}
+
+			res = r.decl(res, decl)
+		}
+
+		for _, grp := range file.Comments {
+			r.failComment(grp)
+		}
+
+		return res
+	})
+}
+
+// MustTemplate returns t, and panics if err is not nil; it eases the declaration of templates as globals.
+func MustTemplate[T any](t *Template[T], err error) *Template[T] {
+	if err != nil {
+		panic(err)
+	}
+
+	return t
+}
+
+func newTemplate[T any](fset *token.FileSet, root ast.Node, imports []string, read func(r *astReader) T) (*Template[T], error) {
+	res := &Template[T]{
+		fset:  fset,
+		paths: make(map[string]string),
+		read:  read,
+	}
+
+	for _, path := range imports {
+		res.paths[string(defaultPkgName(path))] = path
+	}
+
+	ast.Inspect(root, func(n ast.Node) bool {
+		if id, ok := n.(*ast.Ident); ok {
+			if name, ok := strings.CutPrefix(id.Name, placeholderPrefix); ok && !slices.Contains(res.params, name) {
+				res.params = append(res.params, name)
+			}
+		}
+
+		return true
+	})
+
+	// convert the template once, with the placeholders left as identifiers, to report the nodes
+	// that cannot be represented
+	r := res.reader(nil)
+	r.checkSelectors(root)
+	read(r)
+
+	if err := r.result(); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// Params returns the names of the placeholders of the template, in order of appearance.
+func (t *Template[T]) Params() []string {
+	return slices.Clone(t.params)
+}
+
+// Exec returns the nodes of the template, where the placeholders are replaced by args. Missing and
+// unknown arguments, and arguments of the wrong kind, are reported as ParseErrors.
+func (t *Template[T]) Exec(args TemplateArgs) (T, error) {
+	var errs ParseErrors
+
+	for name := range args {
+		if !slices.Contains(t.params, name) {
+			errs = append(errs, ParseError{Msg: fmt.Sprintf("unknown template argument '%s'", name)})
+		}
+	}
+
+	if args == nil {
+		args = TemplateArgs{}
+	}
+
+	r := t.reader(args)
+	res := t.read(r)
+	r.errs = append(errs, r.errs...)
+
+	return res, r.result()
+}
+
+func (t *Template[T]) reader(args TemplateArgs) *astReader {
+	r := newAstReader(t.fset)
+	r.paths = t.paths
+	r.args = args
+
+	return r
+}
+
+// expandPlaceholders replaces the $ of the placeholders of src by placeholderPrefix; the $ found in
+// literals and comments are left untouched.
+func expandPlaceholders(src string) string {
+	file := token.NewFileSet().AddFile("", -1, len(src))
+
+	var scn scanner.Scanner
+	scn.Init(file, []byte(src), nil, scanner.ScanComments)
+
+	res := strings.Builder{}
+	last := 0
+
+	for {
+		pos, tok, _ := scn.Scan()
+		if tok == token.EOF {
+			break
+		}
+
+		// the scanner reads $name as an illegal $ followed by an identifier
+		if off := file.Offset(pos); tok == token.ILLEGAL && src[off] == '$' && off+1 < len(src) && isIdentStart(src[off+1]) {
+			res.WriteString(src[last:off] + placeholderPrefix)
+			last = off + 1
+		}
+	}
+
+	res.WriteString(src[last:])
+	return res.String()
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+// placeholder returns the argument of the placeholder id, if id is a placeholder of the template being executed.
+func (r *astReader) placeholder(id *ast.Ident) (any, bool) {
+	if r.args == nil {
+		return nil, false
+	}
+
+	name, ok := strings.CutPrefix(id.Name, placeholderPrefix)
+	if !ok {
+		return nil, false
+	}
+
+	arg, ok := r.args[name]
+	if !ok {
+		r.fail(id, "missing template argument '%s'", name)
+	}
+
+	return arg, true
+}
+
+func (r *astReader) id(id *ast.Ident) ID {
+	arg, ok := r.placeholder(id)
+	if !ok {
+		return ID(id.Name)
+	}
+
+	switch arg := arg.(type) {
+	case ID:
+		return arg
+	case string:
+		return ID(arg)
+	case nil:
+		return ""
+	}
+
+	r.fail(id, "template argument '%s' must be an identifier; %T found", id.Name[len(placeholderPrefix):], arg)
+	return ""
+}
+
+func (r *astReader) exprArg(id *ast.Ident, arg any) Expr {
+	switch arg := arg.(type) {
+	case Expr:
+		return unwrapExpr(arg)
+	case ID:
+		return Symbol{ID: arg}
+	case nil:
+		return nil
+	}
+
+	r.fail(id, "template argument '%s' must be an expression; %T found", id.Name[len(placeholderPrefix):], arg)
+	return nil
+}
+
+func (r *astReader) typeArg(id *ast.Ident, arg any) Type {
+	switch arg := arg.(type) {
+	case Type:
+		return arg
+	case ID:
+		return Symbol{ID: arg}
+	case nil:
+		return nil
+	}
+
+	r.fail(id, "template argument '%s' must be a type; %T found", id.Name[len(placeholderPrefix):], arg)
+	return nil
+}
+
+func (r *astReader) stmtArg(id *ast.Ident, arg any) Stmt {
+	switch arg := arg.(type) {
+	case Stmt:
+		return arg
+	case Expr:
+		return ExprStmt{Expr: arg}
+	case nil:
+		return nil
+	}
+
+	r.fail(id, "template argument '%s' must be a statement; %T found", id.Name[len(placeholderPrefix):], arg)
+	return nil
+}
+
+// stmtsArg returns the statements replacing stmt, when it is a placeholder replaced by a []Stmt.
+func (r *astReader) stmtsArg(stmt ast.Stmt) ([]Stmt, bool) {
+	expr, ok := stmt.(*ast.ExprStmt)
+	if !ok {
+		return nil, false
+	}
+
+	id, ok := expr.X.(*ast.Ident)
+	if !ok || r.args == nil {
+		return nil, false
+	}
+
+	name, ok := strings.CutPrefix(id.Name, placeholderPrefix)
+	if !ok {
+		return nil, false
+	}
+
+	res, ok := r.args[name].([]Stmt)
+	return res, ok
+}
+
+// checkSelectors reports the selectors on identifiers that are neither imported packages, placeholders
+// nor declared within root; they would reference packages the unit using the template does not import.
+func (r *astReader) checkSelectors(root ast.Node) {
+	declared := make(map[string]bool)
+
+	declare := func(ids ...*ast.Ident) {
+		for _, id := range ids {
+			declared[id.Name] = true
+		}
+	}
+
+	ast.Inspect(root, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			if n.Tok == token.DEFINE {
+				for _, itm := range n.Lhs {
+					if id, ok := itm.(*ast.Ident); ok {
+						declare(id)
+					}
+				}
+			}
+		case *ast.RangeStmt:
+			if n.Tok == token.DEFINE {
+				for _, itm := range []ast.Expr{n.Key, n.Value} {
+					if id, ok := itm.(*ast.Ident); ok {
+						declare(id)
+					}
+				}
+			}
+		case *ast.ValueSpec:
+			declare(n.Names...)
+		case *ast.TypeSpec:
+			declare(n.Name)
+		case *ast.Field:
+			declare(n.Names...)
+		}
+
+		return true
+	})
+
+	ast.Inspect(root, func(n ast.Node) bool {
+		sel, ok := n.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+
+		id, ok := sel.X.(*ast.Ident)
+		if !ok {
+			return true
+		}
+
+		if _, ok := r.paths[id.Name]; !ok && !declared[id.Name] && !strings.HasPrefix(id.Name, placeholderPrefix) {
+			r.fail(id, "package '%s' is not imported by the template", id.Name)
+		}
+
+		return true
+	})
+}
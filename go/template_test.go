@@ -0,0 +1,128 @@
+package golang_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	code "github.com/trwk76/go-code"
+	golang "github.com/trwk76/go-code/go"
+)
+
+var loadTmpl = golang.MustTemplate(golang.StmtsTemplate(`
+$var, err := os.ReadFile($path)
+if err != nil {
+	return fmt.Errorf("reading %s: %w", $path, err)
+}
+$check
+`, "os", "fmt"))
+
+func TestTemplate(t *testing.T) {
+	if params := loadTmpl.Params(); len(params) != 3 || params[0] != "var" || params[1] != "path" || params[2] != "check" {
+		t.Errorf("unexpected params %v", params)
+	}
+
+	body, err := loadTmpl.Exec(golang.TemplateArgs{
+		"var":  golang.ID("data"),
+		"path": golang.Call(golang.Symbol{Path: "path/filepath", ID: "Join"}).Args(golang.Ident("dir"), golang.StringExpr("data")),
+		"check": []golang.Stmt{
+			golang.Ident("use").Call(golang.Ident("data")).Stmt(),
+			golang.Return(golang.Nil),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	unit := golang.Unit{
+		Package: "my",
+		Decls: golang.Decls{golang.FuncDecls{
+			golang.Func("Load").Params(golang.Param{ID: "dir", Type: golang.String}).Returns(golang.Error).Body(body...),
+		}},
+	}
+
+	text := code.WriteString("\t", func(w *code.Writer) {
+		if err := unit.Write(w); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	if text != `package my
+
+import (
+	fmt "fmt"
+	os "os"
+	filepath "path/filepath"
+)
+
+func Load(dir string) error {
+	data, err := os.ReadFile(filepath.Join(dir, "data"))
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", filepath.Join(dir, "data"), err)
+	}
+	use(data)
+	return nil
+}
+` {
+		t.Errorf("unexpected output:\n%s", text)
+	}
+
+	var errs golang.ParseErrors
+
+	if _, err := loadTmpl.Exec(golang.TemplateArgs{"var": 42, "path": golang.StringExpr("x"), "extra": golang.Nil}); !errors.As(err, &errs) || len(errs) != 3 {
+		t.Errorf("expected errors for the unknown, missing and invalid arguments; got: %v", err)
+	}
+
+	decls, err := golang.MustTemplate(golang.DeclsTemplate(`
+import "time"
+
+type $name struct {
+	At $type
+	Timeout time.Duration
+}
+`)).Exec(golang.TemplateArgs{"name": "Event", "type": golang.Int64})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	unit = golang.Unit{Package: "my", Decls: decls}
+	text, err = code.WriteStringErr("\t", func(w *code.Writer) { unit.Write(w) })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if text != `package my
+
+import time "time"
+
+type Event struct {
+	At      int64
+	Timeout time.Duration
+}
+` {
+		t.Errorf("unexpected output:\n%s", text)
+	}
+
+	if _, err := golang.StmtsTemplate("f($x[0:1:2])"); err == nil {
+		t.Error("expected unrepresentable templates to fail when parsed")
+	}
+
+	price := golang.MustTemplate(golang.StmtsTemplate(`fmt.Println("price in $USD", $x)`, "fmt"))
+	if params := price.Params(); len(params) != 1 || params[0] != "x" {
+		t.Errorf("unexpected params %v", params)
+	}
+
+	if stmts, err := price.Exec(golang.TemplateArgs{"x": golang.IntExpr(3)}); err != nil {
+		t.Error(err)
+	} else if !reflect.DeepEqual(stmts, golang.Block(golang.Call(golang.Symbol{Path: "fmt", ID: "Println"}).Args(golang.StringExpr("price in $USD"), golang.IntExpr(3)).Stmt())) {
+		t.Errorf("unexpected statements: %#v", stmts)
+	}
+
+	if _, err := golang.ExprTemplate("strings.TrimSpace($x)"); !errors.As(err, &errs) || len(errs) != 1 {
+		t.Errorf("expected an error for the package not imported; got: %v", err)
+	}
+
+	if _, err := golang.StmtsTemplate("for _, r := range $rules {\n\tcheck(r.Name, $req.Body)\n}"); err != nil {
+		t.Errorf("expected selectors on declared identifiers and placeholders to be accepted; got: %v", err)
+	}
+}
@@ -0,0 +1,62 @@
+// Package loaded is loaded from source by the tests of Loader.
+package loaded
+
+import (
+	"io"
+	"strings"
+	"time"
+)
+
+type (
+	Number interface {
+		~int | ~int64 | ~float64
+	}
+
+	Set[T comparable] struct {
+		items map[T]bool
+	}
+
+	Entry struct {
+		io.Reader
+		Name    string `json:"name"`
+		Timeout time.Duration
+	}
+
+	ID = string
+)
+
+func NewSet[T comparable](items []T) *Set[T] {
+	res := &Set[T]{items: make(map[T]bool)}
+
+	for _, itm := range items {
+		res.Add(itm)
+	}
+
+	return res
+}
+
+func Sum[N ~int | ~float64](values []N) (total N) {
+	for _, v := range values {
+		total += v
+	}
+
+	return total
+}
+
+func Join(sep string, parts ...string) string {
+	return strings.Join(parts, sep)
+}
+
+func (s *Set[T]) Add(v T) {
+	s.items[v] = true
+}
+
+func (s *Set[T]) AddAll(items ...T) {
+	for _, itm := range items {
+		s.Add(itm)
+	}
+}
+
+func (s Set[T]) Has(v T) bool {
+	return s.items[v]
+}
@@ -0,0 +1,26 @@
+// THIS FILE IS AUTOMATICALLY GENERATED; DO NOT EDIT
+
+package my_test
+
+const (
+	KindA, KindB = iota, iota + 10
+	KindC, KindD
+)
+
+var (
+	width, height int
+	left, right   = "l", "r"
+)
+
+func Accumulate(values []int) (sum int, flags uint) {
+	var count, total = 0, len(values)
+	for i := 0; i < total; i++ {
+		sum += values[i]
+		flags |= 1 << i
+		count++
+	}
+	flags <<= 1
+	total--
+	_ = count
+	return
+}
@@ -0,0 +1,22 @@
+// THIS FILE IS AUTOMATICALLY GENERATED; DO NOT EDIT
+
+package my_test
+
+import (
+	_ "embed"
+	errors "errors"
+	htmltemplate "html/template"
+	rand "math/rand"
+	mathrand "math/rand/v2"
+	texttemplate "text/template"
+)
+
+type template struct{}
+
+var (
+	textTmpl = texttemplate.New("text")
+	htmlTmpl = htmltemplate.New("html")
+	randInt  = rand.Int()
+	randN    = mathrand.N(10)
+	errEmpty = errors.New("empty")
+)
@@ -0,0 +1,52 @@
+// THIS FILE IS AUTOMATICALLY GENERATED; DO NOT EDIT
+
+// Package my_test shows doc comments.
+//
+// # Usage
+//
+// Shapes are measured by:
+//
+//   - their area
+//   - their perimeter,
+//     when closed
+//
+// Steps:
+//
+//  1. create a shape
+//  2. measure it
+//
+// For example:
+//
+//	s := Shape{}
+//	fmt.Println(s.Area())
+package my_test
+
+//go:generate stringer -type=Kind
+
+/* Shapes are immutable. */
+
+type (
+	// Shape is a 2D shape; see [image.Rectangle].
+	Shape struct {
+		// Width is in [Units].
+		Width  float64
+		Height float64
+	}
+
+	// Measurer measures a [Shape].
+	//
+	// [Units]: https://en.wikipedia.org/wiki/SI
+	Measurer interface {
+		// Measure returns the area of s.
+		Measure(s Shape) float64
+	}
+)
+
+// Area returns the area of s.
+//
+// Deprecated: use a Measurer instead.
+//
+//go:noinline
+func Area(s Shape) float64 {
+	return s.Width * s.Height
+}
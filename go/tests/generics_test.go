@@ -0,0 +1,37 @@
+package my_test
+
+type (
+	Ordered interface {
+		~int | ~float64 | ~string
+		comparable
+	}
+
+	Set[T comparable] struct {
+		items map[T]bool
+	}
+
+	Pair[K, V any] struct {
+		Key   K
+		Value V
+	}
+)
+
+func (s *Set[T]) Add(v T) {
+	s.items[v] = true
+}
+
+func Largest[T ~int | ~float64](a, b T) T {
+	if a > b {
+		return a
+	}
+	return b
+}
+func LargestInt(a, b int) int              { return Largest[int](a, b) }
+func NewPair(key string) Pair[string, int] { return MakePair[string, int](key, 0) }
+
+func MakePair[K, V any](key K, value V) Pair[K, V] {
+	return Pair[K, V]{
+		Key:   key,
+		Value: value,
+	}
+}
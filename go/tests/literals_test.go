@@ -0,0 +1,30 @@
+package my_test
+
+const (
+	ModeMask = 0o0777
+	FlagBits = 0b00000101
+	Magic    = 0xcafe
+	Offset   = -0x10
+	Quoted   = `use ` + "`" + `go` + "`" + ` here`
+)
+
+var selectUsers = `
+SELECT id, name
+FROM users
+WHERE id = $1
+`
+
+var (
+	asset = []byte{
+		0x00, 0x07, 0x0e, 0x15, 0x1c, 0x23, 0x2a, 0x31, 0x38, 0x3f, 0x46, 0x4d, 0x54, 0x5b, 0x62, 0x69,
+		0x70, 0x77, 0x7e, 0x85, 0x8c, 0x93, 0x9a, 0xa1, 0xa8, 0xaf, 0xb6, 0xbd, 0xc4, 0xcb, 0xd2, 0xd9,
+		0xe0, 0xe7, 0xee, 0xf5, 0xfc, 0x03, 0x0a, 0x11,
+	}
+
+	header = []byte{0x89, 0x50, 0x4e, 0x47}
+)
+
+func countUsers() string {
+	return `SELECT count(*)
+	FROM users`
+}
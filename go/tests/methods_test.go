@@ -0,0 +1,30 @@
+// THIS FILE IS AUTOMATICALLY GENERATED; DO NOT EDIT
+
+package my_test
+
+import (
+	fmt "fmt"
+	strconv "strconv"
+)
+
+type Setter interface {
+	Set(v float64)
+}
+
+type (
+	Celsius struct {
+		Value float64
+	}
+
+	Level uint8
+)
+
+func (c Celsius) String() string { return fmt.Sprintf("%.1f°C", c.Value) }
+func (c *Celsius) Set(v float64) { c.Value = v }
+func (l Level) String() string   { return strconv.Itoa(int(l)) }
+
+var (
+	_ fmt.Stringer = Celsius{}
+	_ Setter       = (*Celsius)(nil)
+	_ fmt.Stringer = Level(0)
+)
@@ -0,0 +1,21 @@
+// THIS FILE IS AUTOMATICALLY GENERATED; DO NOT EDIT
+
+package my_test
+
+type Store struct {
+	items []string
+}
+
+func (s *Store) Add(item string) error {
+	// user code begin: Store.Add
+	return nil
+	// user code end: Store.Add
+}
+
+// user code begin: helpers
+
+func storeSize(s *Store) int {
+	return len(s.items)
+}
+
+// user code end: helpers
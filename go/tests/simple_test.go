@@ -12,11 +12,10 @@ type ID struct {
 	Value uuid.UUID `json:"value"`
 }
 
-func (i ID)  MarshalText() ([]byte, error)   { return i.Value.MarshalText() }
+func (i ID) MarshalText() ([]byte, error)    { return i.Value.MarshalText() }
 func (i *ID) UnmarshalText(raw []byte) error { return i.Value.UnmarshalText(raw) }
 
 var (
 	_ encoding.TextMarshaler   = ID{}
 	_ encoding.TextUnmarshaler = (*ID)(nil)
 )
-
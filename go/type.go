@@ -2,6 +2,7 @@ package golang
 
 import (
 	"errors"
+	"fmt"
 	"strconv"
 	"strings"
 
@@ -33,6 +34,15 @@ var (
 	UintPtr    Symbol = Symbol{ID: "uintptr"}
 )
 
+const (
+	// ChanBoth is a bidirectional channel (chan T).
+	ChanBoth ChanDir = iota
+	// ChanRecv is a receive-only channel (<-chan T).
+	ChanRecv
+	// ChanSend is a send-only channel (chan<- T).
+	ChanSend
+)
+
 type (
 	Type interface {
 		TypeSpec
@@ -54,8 +64,21 @@ type (
 		Value Type
 	}
 
+	ChanType struct {
+		Dir  ChanDir
+		Item Type
+	}
+
+	FuncType struct {
+		Params Params
+		Return Params
+	}
+
 	InterfaceType struct {
+		// Consts is the union of the type set of the interface.
 		Consts GenConsts
+		// Unions are further unions, one per line after Consts; the type set is their intersection.
+		Unions []GenConsts
 		Meths  []InterfaceMeth
 	}
 
@@ -64,16 +87,21 @@ type (
 		Fields []StructField
 	}
 
+	ChanDir uint8
+
 	GenConsts []GenConst
 
 	InterfaceMeth struct {
-		ID     ID
-		Params Params
-		Return Params
+		Comment Comment
+		Doc     Doc
+		ID      ID
+		Params  Params
+		Return  Params
 	}
 
 	StructField struct {
 		Comment Comment
+		Doc     Doc
 		ID      ID
 		Type    Type
 		Tags    Tags
@@ -103,6 +131,23 @@ func (s Symbol) writeTypeSpec(w *code.Writer) {
 	s.write(w)
 }
 
+// NilExpr is also a Type so that nil can be listed in the cases of a TypeSwitchStmt.
+func (e NilExpr) simpleType() bool {
+	return true
+}
+
+func (e NilExpr) simpleTypeSpec() bool {
+	return true
+}
+
+func (e NilExpr) writeType(w *code.Writer) {
+	e.writeExpr(w, true)
+}
+
+func (e NilExpr) writeTypeSpec(w *code.Writer) {
+	e.writeType(w)
+}
+
 func (t PtrType) simpleType() bool {
 	return (t.Item == nil || t.Item.simpleType())
 }
@@ -160,8 +205,62 @@ func (s MapType) writeTypeSpec(w *code.Writer) {
 	s.writeType(w)
 }
 
+func (t ChanType) simpleType() bool {
+	return t.Item == nil || t.Item.simpleType()
+}
+
+func (t ChanType) simpleTypeSpec() bool {
+	return t.simpleType()
+}
+
+func (t ChanType) writeType(w *code.Writer) {
+	switch t.Dir {
+	case ChanBoth:
+		w.WriteString("chan ")
+	case ChanRecv:
+		w.WriteString("<-chan ")
+	case ChanSend:
+		w.WriteString("chan<- ")
+	default:
+		w.Fail(fmt.Errorf("invalid channel direction %d", t.Dir))
+		return
+	}
+
+	if itm, ok := t.Item.(ChanType); ok && t.Dir == ChanBoth && itm.Dir == ChanRecv {
+		// chan <-chan T would be parsed as chan<- chan T
+		w.WriteByte('(')
+		itm.writeType(w)
+		w.WriteByte(')')
+		return
+	}
+
+	writeType(w, t.Item, "channel type requires an item type")
+}
+
+func (t ChanType) writeTypeSpec(w *code.Writer) {
+	t.writeType(w)
+}
+
+func (t FuncType) simpleType() bool {
+	return t.Params.simpleParams() && t.Return.simpleParams()
+}
+
+func (t FuncType) simpleTypeSpec() bool {
+	return t.simpleType()
+}
+
+func (t FuncType) writeType(w *code.Writer) {
+	w.WriteString("func")
+	t.Params.write(w, true)
+	t.Return.write(w, false)
+}
+
+func (t FuncType) writeTypeSpec(w *code.Writer) {
+	t.writeType(w)
+}
+
 func (t InterfaceType) simpleType() bool {
-	return len(t.Consts) < 1 && len(t.Meths) < 1
+	return len(t.Consts) < 1 && len(t.Unions) < 1 && len(t.Meths) < 1
 }
 
 func (t InterfaceType) simpleTypeSpec() bool {
@@ -169,34 +268,39 @@ func (t InterfaceType) simpleTypeSpec() bool {
 }
 
 func (t InterfaceType) writeType(w *code.Writer) {
-	w.WriteString("interface {")
-
-	if len(t.Consts) > 0 || len(t.Meths) > 0 {
-		w.Newline()
-		w.Indent(func(w *code.Writer) {
-			if len(t.Consts) > 0 {
-				for idx, itm := range t.Consts {
-					if idx > 0 {
-						w.WriteString(" | ")
-					}
-
-					itm.writeConstraint(w)
-				}
+	if t.simpleType() {
+		w.WriteString("interface{}")
+		return
+	}
 
-				w.Newline()
+	unions := t.Unions
+	if len(t.Consts) > 0 {
+		unions = append([]GenConsts{t.Consts}, unions...)
+	}
 
-				if len(t.Meths) > 0 {
-					w.Newline()
-				}
+	w.WriteString("interface {")
+	w.Newline()
+	w.Indent(func(w *code.Writer) {
+		for _, itm := range unions {
+			if len(itm) < 1 {
+				w.Fail(errors.New("interface union requires terms"))
+				continue
 			}
 
-			for _, itm := range t.Meths {
-				itm.write(w)
-				w.Newline()
-			}
-		})
-	}
+			itm.writeConstraints(w)
+			w.Newline()
+		}
 
+		if len(unions) > 0 && len(t.Meths) > 0 {
+			w.Newline()
+		}
+
+		for _, itm := range t.Meths {
+			writeComment(w, itm.Comment, itm.Doc)
+			itm.write(w)
+			w.Newline()
+		}
+	})
 	w.WriteByte('}')
 }
 
@@ -219,43 +323,50 @@ func (t StructType) simpleTypeSpec() bool {
 }
 
 func (t StructType) writeType(w *code.Writer) {
-	w.WriteString("struct {")
+	if len(t.Bases) < 1 && len(t.Fields) < 1 {
+		w.WriteString("struct{}")
+		return
+	}
 
-	if len(t.Bases) > 0 || len(t.Fields) > 0 {
-		w.Newline()
-		w.Indent(func(w *code.Writer) {
-			rows := make([]code.TableRow, 0, len(t.Bases)+len(t.Fields)+1)
+	w.WriteString("struct {")
+	w.Newline()
+	w.Indent(func(w *code.Writer) {
+		rows := make([]code.TableRow, 0, len(t.Bases)+len(t.Fields)+1)
+
+		for _, base := range t.Bases {
+			rows = append(rows, code.TableRow{
+				Columns: []string{typeString(w, base, "base must not be null")},
+			})
+		}
 
-			for _, base := range t.Bases {
-				rows = append(rows, code.TableRow{
-					Columns: []string{typeString(base, "base must not be null")},
-				})
-			}
+		if len(t.Bases) > 0 && len(t.Fields) > 0 {
+			rows = append(rows, code.TableRow{})
+		}
 
-			if len(t.Bases) > 0 && len(t.Fields) > 0 {
-				rows = append(rows, code.TableRow{})
+		for _, fld := range t.Fields {
+			cols := []string{
+				idString(w, fld.ID),
+				typeString(w, fld.Type, "struct field requires a type"),
 			}
 
-			for _, fld := range t.Fields {
-				cols := []string{
-					idString(fld.ID),
-					typeString(fld.Type, "struct field requires a type"),
-				}
-
-				if tag := fld.Tags.String(); tag != "" {
-					cols = append(cols, tag)
+			for _, tag := range fld.Tags {
+				if tag.Name == "" {
+					w.Fail(errors.New("tag name must not be empty"))
 				}
+			}
 
-				rows = append(rows, code.TableRow{
-					Prefix:  string(fld.Comment),
-					Columns: cols,
-				})
+			if tag := fld.Tags.String(); tag != "" {
+				cols = append(cols, tag)
 			}
 
-			w.Table(rows...)
-		})
-	}
+			rows = append(rows, code.TableRow{
+				Prefix:  commentString(w, fld.Comment, fld.Doc),
+				Columns: cols,
+			})
+		}
 
+		w.Table(rows...)
+	})
 	w.WriteByte('}')
 }
 
@@ -264,10 +375,6 @@ func (t StructType) writeTypeSpec(w *code.Writer) {
 }
 
 func (t Tag) String() string {
-	if t.Name == "" {
-		panic(errors.New("tag name must not be empty"))
-	}
-
 	return t.Name + ":" + strconv.Quote(t.Value)
 }
 
@@ -295,14 +402,14 @@ func (a GenArgs) simpleGenArgs() bool {
 	return true
 }
 
-func typeString(t Type, reqMessage string) string {
-	return writeString(func(w *code.Writer) { writeType(w, t, reqMessage) })
+func typeString(w *code.Writer, t Type, reqMessage string) string {
+	return w.Capture(func(w *code.Writer) { writeType(w, t, reqMessage) })
 }
 
 func writeType(w *code.Writer, t Type, reqMessage string) {
 	if t == nil {
 		if reqMessage != "" {
-			panic(errors.New(reqMessage))
+			w.Fail(errors.New(reqMessage))
 		}
 
 		return
@@ -313,9 +420,12 @@ func writeType(w *code.Writer, t Type, reqMessage string) {
 
 var (
 	_ Type = Symbol{}
+	_ Type = NilExpr{}
 	_ Type = PtrType{}
 	_ Type = SliceType{}
 	_ Type = MapType{}
+	_ Type = ChanType{}
+	_ Type = FuncType{}
 	_ Type = InterfaceType{}
 	_ Type = StructType{}
 )
@@ -1,47 +1,196 @@
 package golang
 
 import (
+	"errors"
 	"fmt"
+	"go/build/constraint"
+	"go/format"
+	"go/token"
+	"io"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
+	"unicode"
 
 	code "github.com/trwk76/go-code"
 )
 
+const (
+	cgoPath   = "C"
+	dotImport = PkgName(".")
+)
+
+// generatedHeader matches the header written for Unit.Generator.
+var generatedHeader = regexp.MustCompile(`^// Code generated by (.+)\. DO NOT EDIT\.$`)
+
 type (
 	Unit struct {
-		Prefix  Comment
+		// Generator, when not empty, is written in the "// Code generated by Generator. DO NOT EDIT."
+		// header recognized by go vet and other tools.
+		Generator string
+		Prefix    Comment
+		// Build is the build constraint expression of the //go:build line (e.g. "linux && !cgo").
+		Build string
+		// Doc is the package documentation.
+		Doc     Doc
 		Package PkgName
+		// Cgo is the preamble of the import "C" declaration, written only when not empty.
+		Cgo     string
 		Imports Imports
 		Decls   Decls
 	}
 
 	Imports struct {
-		sys []PkgRef
-		ext []PkgRef
+		sys      []PkgRef
+		ext      []PkgRef
+		comments map[string]Comment
+		// err is the first error of Ensure, reported when the unit is written.
+		err error
+	}
+
+	// importScope tracks the packages referenced while a unit is written, and the aliases
+	// under which they are imported.
+	importScope struct {
+		used    map[string]bool
+		order   []string
+		aliases map[string]PkgName
 	}
+
+	importScopeKey struct{}
 )
 
-func (u Unit) Write(w *code.Writer) {
+// Write writes the unit, formatted exactly as gofmt would, and returns the error of w, if any.
+// Invalid nodes are reported as a *code.Error, located in the unit as written before formatting.
+func (u Unit) Write(w *code.Writer) error {
+	Formatted(func(w *code.Writer) { u.write(w, nil) })(w)
+	return w.Err()
+}
+
+// Formatted returns a WriteFunc writing the Go source written by f once formatted by go/format;
+// f may write a complete source file or a partial one (declaration or statement list).
+// The writer fails if f does not write valid Go source.
+func Formatted(f code.WriteFunc) code.WriteFunc {
+	return func(w *code.Writer) {
+		src := w.Capture(f)
+		if w.Err() != nil {
+			return
+		}
+
+		res, err := format.Source([]byte(src))
+		if err != nil {
+			w.Fail(fmt.Errorf("invalid Go source: %w", err))
+			return
+		}
+
+		w.Write(res)
+	}
+}
+
+// write writes the unit; when declLines is not nil, it receives the line (relative to the start of the unit)
+// at which each declaration starts.
+func (u Unit) write(w *code.Writer, declLines []int) {
+	if u.Imports.err != nil {
+		w.Fail(u.Imports.err)
+		return
+	}
+
+	// Write the declarations a first time to find out which packages they reference.
+	scope := newImportScope()
+	tmp := code.NewWriter(io.Discard, "")
+	tmp.SetValue(importScopeKey{}, scope)
+
+	for _, decl := range u.Decls {
+		decl.writeDecl(&tmp)
+	}
+
+	if u.Cgo != "" {
+		// C symbols are provided by the import "C" declaration
+		scope.aliases[cgoPath] = cgoPath
+	}
+
+	imports := u.Imports.resolve(scope, u.Decls.names())
+
+	prev := w.Value(importScopeKey{})
+	w.SetValue(importScopeKey{}, scope)
+	defer w.SetValue(importScopeKey{}, prev)
+
+	start := w.Line()
+
+	if u.Generator != "" {
+		if strings.ContainsAny(u.Generator, "\r\n") {
+			w.Fail(fmt.Errorf("invalid generator '%s'", u.Generator))
+		}
+
+		w.WriteString("// Code generated by " + u.Generator + ". DO NOT EDIT.")
+		w.Newline()
+		w.Newline()
+	}
+
 	u.Prefix.write(w)
 
 	if len(u.Prefix) > 0 {
 		w.Newline()
 	}
 
-	fmt.Fprintf(w, "package %s", u.Package)
+	if u.Build != "" {
+		expr, err := constraint.Parse("//go:build " + u.Build)
+		if err != nil {
+			w.Fail(fmt.Errorf("invalid build constraint '%s': %w", u.Build, err))
+		} else {
+			w.WriteString("//go:build " + expr.String())
+			w.Newline()
+			w.Newline()
+		}
+	}
+
+	writeComment(w, "", u.Doc)
+	w.WriteString("package ")
+	u.Package.write(w)
 	w.Newline()
-	u.Imports.write(w)
 
-	for _, decl := range u.Decls {
-		decl.writeDecl(w)
+	if u.Cgo != "" {
+		if strings.Contains(u.Cgo, "*/") {
+			w.Fail(errors.New("cgo preamble must not contain '*/'"))
+		}
+
+		w.Newline()
+		w.WriteString("/*")
+		w.Newline()
+		w.WriteString(strings.TrimRight(u.Cgo, "\n"))
+		w.Newline()
+		w.WriteString("*/")
+		w.Newline()
+		w.WriteString(`import "C"`)
+		w.Newline()
 	}
 
-	w.Newline()
+	imports.write(w)
+
+	for idx, decl := range u.Decls {
+		if declLines != nil {
+			declLines[idx] = w.Line() - start + 1
+		}
+
+		decl.writeDecl(w)
+	}
 }
 
+// Ensure imports the package at path under alias, or under its default name when alias is empty, and
+// returns its reference. An invalid alias or path, or an alias other than the one the package is already
+// imported under, is reported when the unit is written.
 func (i *Imports) Ensure(alias PkgName, path string) PkgRef {
+	ref, err := i.ensure(alias, path)
+	if err != nil && i.err == nil {
+		i.err = err
+	}
+
+	return ref
+}
+
+func (i *Imports) ensure(alias PkgName, path string) (PkgRef, error) {
 	if path == "" {
-		panic(fmt.Errorf("import path must not be empty"))
+		return PkgRef{alias: alias}, errors.New("import path must not be empty")
 	}
 
 	dest := &i.sys
@@ -50,33 +199,41 @@ func (i *Imports) Ensure(alias PkgName, path string) PkgRef {
 	}
 
 	if alias == "" {
-		idx := strings.LastIndexByte(path, '/')
+		alias = defaultPkgName(path)
+	}
 
-		if idx > 0 {
-			alias = PkgName(path[idx+1:])
-		} else {
-			alias = PkgName(path)
+	ref := PkgRef{alias: alias, path: path}
+
+	if alias != dotImport {
+		if err := alias.check(); err != nil {
+			return ref, err
 		}
 	}
 
-	alias.check()
-
 	for idx, imp := range *dest {
 		if imp.path == path {
 			if imp.alias == PkgName(Ignore) {
 				(*dest)[idx].alias = alias
-				return imp
+				return (*dest)[idx], nil
 			} else if imp.alias == alias {
-				return imp
+				return imp, nil
 			} else {
-				panic(fmt.Errorf("package alias '%s' already exists for package '%s'", alias, path))
+				return ref, fmt.Errorf("package alias '%s' already exists for package '%s'", alias, path)
 			}
 		}
 	}
 
-	ref := PkgRef{alias: alias, path: path}
 	*dest = append(*dest, ref)
-	return ref
+	return ref, nil
+}
+
+// Comment sets the comment written before the import of the package at path.
+func (i *Imports) Comment(path string, c Comment) {
+	if i.comments == nil {
+		i.comments = make(map[string]Comment)
+	}
+
+	i.comments[path] = c
 }
 
 func (i Imports) write(w *code.Writer) {
@@ -97,6 +254,7 @@ func (i Imports) write(w *code.Writer) {
 		}
 
 		w.Newline()
+		i.comments[item.path].write(w)
 		w.WriteString("import ")
 		item.write(w)
 		w.Newline()
@@ -107,6 +265,7 @@ func (i Imports) write(w *code.Writer) {
 		w.Newline()
 		w.Indent(func(w *code.Writer) {
 			for _, item := range i.sys {
+				i.comments[item.path].write(w)
 				item.write(w)
 				w.Newline()
 			}
@@ -116,6 +275,7 @@ func (i Imports) write(w *code.Writer) {
 			}
 
 			for _, item := range i.ext {
+				i.comments[item.path].write(w)
 				item.write(w)
 				w.Newline()
 			}
@@ -125,6 +285,214 @@ func (i Imports) write(w *code.Writer) {
 	}
 }
 
+// resolve returns the imports actually used by the symbols recorded in scope: unused imports are dropped
+// (blank and dot imports are kept) and the packages referenced by path only are added under an alias that
+// does not collide with other imports or with the names in reserved.
+func (i Imports) resolve(scope *importScope, reserved map[PkgName]bool) Imports {
+	res := Imports{comments: i.comments}
+	taken := make(map[PkgName]bool)
+
+	for name := range reserved {
+		taken[name] = true
+	}
+
+	for _, imp := range slices.Concat(i.sys, i.ext) {
+		if imp.alias == PkgName(Ignore) {
+			if !scope.used[imp.path] {
+				res.add(imp)
+			}
+		} else if imp.alias == dotImport {
+			res.add(imp)
+			scope.aliases[imp.path] = imp.alias
+		} else if scope.used[imp.path] {
+			res.add(imp)
+			taken[imp.alias] = true
+			scope.aliases[imp.path] = imp.alias
+		}
+	}
+
+	for _, path := range scope.order {
+		if _, ok := scope.aliases[path]; ok {
+			continue
+		}
+
+		alias := uniquePkgName(path, taken)
+		taken[alias] = true
+		scope.aliases[path] = alias
+
+		res.add(PkgRef{alias: alias, path: path})
+	}
+
+	sortImports := func(a, b PkgRef) int { return strings.Compare(a.path, b.path) }
+	slices.SortStableFunc(res.sys, sortImports)
+	slices.SortStableFunc(res.ext, sortImports)
+
+	return res
+}
+
+func (i *Imports) add(ref PkgRef) {
+	if isSysImport(ref.path) {
+		i.sys = append(i.sys, ref)
+	} else {
+		i.ext = append(i.ext, ref)
+	}
+}
+
+func newImportScope() *importScope {
+	return &importScope{
+		used:    make(map[string]bool),
+		aliases: make(map[string]PkgName),
+	}
+}
+
+func (s *importScope) use(path string) {
+	if !s.used[path] {
+		s.used[path] = true
+		s.order = append(s.order, path)
+	}
+}
+
+// useImport records that the package at path is referenced by the unit being written.
+func useImport(w *code.Writer, path string) {
+	if scope, ok := w.Value(importScopeKey{}).(*importScope); ok {
+		scope.use(path)
+	}
+}
+
+// importAlias records that the package at path is referenced by the unit being written and returns
+// the alias it is imported under.
+func importAlias(w *code.Writer, path string) PkgName {
+	if scope, ok := w.Value(importScopeKey{}).(*importScope); ok {
+		scope.use(path)
+
+		if alias, ok := scope.aliases[path]; ok {
+			return alias
+		}
+	}
+
+	return defaultPkgName(path)
+}
+
+// names returns the identifiers declared at package level by the declarations.
+func (d Decls) names() map[PkgName]bool {
+	res := make(map[PkgName]bool)
+
+	for _, decl := range d {
+		switch decl := decl.(type) {
+		case ConstDecls:
+			for _, itm := range decl {
+				for _, id := range itm.ids() {
+					res[PkgName(id)] = true
+				}
+			}
+		case FuncDecls:
+			for _, itm := range decl {
+				res[PkgName(itm.ID)] = true
+			}
+		case TypeDecls:
+			for _, itm := range decl {
+				res[PkgName(itm.ID)] = true
+			}
+		case VarDecls:
+			for _, itm := range decl {
+				for _, id := range itm.ids() {
+					res[PkgName(id)] = true
+				}
+			}
+		case RegionDecl:
+			for name := range decl.Decls.names() {
+				res[name] = true
+			}
+		}
+	}
+
+	return res
+}
+
+// defaultPkgName guesses the name of the package at path, following the usual conventions
+// (major version suffixes, go- prefixes and -go suffixes are ignored).
+func defaultPkgName(path string) PkgName {
+	elems := strings.Split(path, "/")
+	name := elems[len(elems)-1]
+
+	if len(elems) > 1 && isMajorVersion(name) {
+		name = elems[len(elems)-2]
+	}
+
+	if idx := strings.LastIndex(name, ".v"); idx > 0 && isDigits(name[idx+2:]) {
+		// gopkg.in/yaml.v3
+		name = name[:idx]
+	}
+
+	name = strings.TrimPrefix(name, "go-")
+	name = strings.TrimSuffix(name, "-go")
+
+	return sanitizePkgName(name)
+}
+
+func uniquePkgName(path string, taken map[PkgName]bool) PkgName {
+	name := defaultPkgName(path)
+
+	if !taken[name] {
+		return name
+	}
+
+	// Prefix the name with the parent path element (github.com/pkg/errors → pkgerrors).
+	if elems := strings.Split(path, "/"); len(elems) > 1 {
+		parent := elems[len(elems)-2]
+
+		if len(elems) > 2 && isMajorVersion(elems[len(elems)-1]) {
+			parent = elems[len(elems)-3]
+		}
+
+		if res := sanitizePkgName(parent + string(name)); !taken[res] {
+			return res
+		}
+	}
+
+	for idx := 2; ; idx++ {
+		if res := PkgName(string(name) + strconv.Itoa(idx)); !taken[res] {
+			return res
+		}
+	}
+}
+
+func sanitizePkgName(name string) PkgName {
+	buf := strings.Builder{}
+
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' {
+			buf.WriteRune(r)
+		}
+	}
+
+	res := buf.String()
+
+	if res == "" || !token.IsIdentifier(res) {
+		res = "pkg" + res
+	}
+
+	return PkgName(res)
+}
+
+func isMajorVersion(elem string) bool {
+	return len(elem) > 1 && elem[0] == 'v' && isDigits(elem[1:])
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
 func isSysImport(path string) bool {
 	return !strings.Contains(path, ".")
 }
@@ -3,7 +3,10 @@ package golang_test
 import (
 	_ "embed"
 	"encoding"
+	"errors"
 	"fmt"
+	"go/format"
+	"strings"
 	"testing"
 
 	"github.com/google/uuid"
@@ -26,7 +29,11 @@ func TestUnit(t *testing.T) {
 		}()
 
 		unit := item.gen()
-		res := code.WriteString("\t", func(w *code.Writer) { unit.Write(w) })
+		res, err := code.WriteStringErr("\t", func(w *code.Writer) { unit.Write(w) })
+		if err != nil {
+			t.Errorf("test '%s' failed: %s", item.name, err.Error())
+			continue
+		}
 
 		dmp := diffmatchpatch.New()
 		diffs := dmp.DiffMain(res, item.text, false)
@@ -39,6 +46,13 @@ func TestUnit(t *testing.T) {
 			}
 		}
 
+		// Unit.Write runs go/format itself, so check the golden instead: output matching it is gofmt-identical.
+		if fmtd, err := format.Source([]byte(item.text)); err != nil {
+			t.Errorf("test '%s' golden does not parse: %s", item.name, err.Error())
+		} else if string(fmtd) != item.text {
+			t.Errorf("test '%s' golden is not gofmt-formatted", item.name)
+		}
+
 		if !ok {
 			t.Error(res)
 			// t.Errorf("test '%s' failed: %s\n", item.name, dmp.DiffPrettyText(diffs))
@@ -46,6 +60,190 @@ func TestUnit(t *testing.T) {
 	}
 }
 
+func TestUnitWriteError(t *testing.T) {
+	unit := golang.Unit{
+		Package: golang.PkgName("my_test"),
+		Decls: golang.Decls{
+			golang.FuncDecls{
+				{
+					ID: "Run",
+					Body: golang.BlockStmt{
+						golang.AssignStmt{Auto: true, Dests: golang.Exprs{golang.Symbol{ID: "x"}}, Srcs: golang.Exprs{golang.IntExpr(1)}},
+						golang.AssignStmt{Dests: golang.Exprs{golang.Symbol{ID: "x"}}, Srcs: golang.Exprs{golang.Symbol{ID: "not valid"}}},
+						golang.ReturnStmt{},
+					},
+				},
+			},
+		},
+	}
+
+	var err error
+
+	code.WriteString("\t", func(w *code.Writer) { err = unit.Write(w) })
+
+	var werr *code.Error
+
+	if !errors.As(err, &werr) {
+		t.Fatalf("expected a positioned error; got: %v", err)
+	}
+
+	if werr.Line != 5 || werr.Column != 6 {
+		t.Errorf("error reported at wrong position: %s", werr.Error())
+	}
+}
+
+func TestUnitInvalidNodes(t *testing.T) {
+	imps := golang.Imports{}
+	imps.Ensure("Bad", "strings")
+
+	units := map[string]golang.Unit{
+		"alias":   {Package: "my", Decls: golang.Decls{golang.TypeDecls{{ID: "T", Spec: golang.TypeAlias{}}}}},
+		"genArgs": {Package: "my", Decls: golang.Decls{golang.VarDecls{{ID: "v", Type: golang.Symbol{ID: "T", GenArgs: golang.GenArgs{nil}}}}}},
+		"package": {Package: "My Package"},
+		"imports": {Package: "my", Imports: imps},
+		"forNext": {Package: "my", Decls: golang.Decls{golang.FuncDecls{{ID: "f", Body: golang.BlockStmt{golang.ForStmt{
+			Cond: golang.BoolExpr(true),
+			Next: golang.AssignStmt{Auto: true, Dests: golang.Exprs{golang.Symbol{ID: "i"}}, Srcs: golang.Exprs{golang.IntExpr(0)}},
+		}}}}}},
+	}
+
+	for name, unit := range units {
+		var err error
+
+		code.WriteString("\t", func(w *code.Writer) { err = unit.Write(w) })
+
+		var werr *code.Error
+
+		if !errors.As(err, &werr) || strings.Contains(err.Error(), "invalid Go source") {
+			t.Errorf("test '%s': expected a positioned error; got: %v", name, err)
+		}
+	}
+}
+
+func TestUnitMaxWidth(t *testing.T) {
+	point := golang.Symbol{ID: "point"}
+	call := func(args ...golang.Expr) golang.Stmt {
+		return golang.ExprStmt{Expr: golang.CallExpr{Func: golang.Symbol{ID: "Run"}, Args: args}}
+	}
+
+	unit := golang.Unit{
+		Package: golang.PkgName("my_test"),
+		Decls: golang.Decls{
+			golang.FuncDecls{
+				{
+					ID: "Run",
+					Params: golang.Params{
+						{ID: "name", Type: golang.String},
+						{ID: "count", Type: golang.Int},
+						{ID: "origin", Type: point},
+					},
+					Body: golang.BlockStmt{
+						call(golang.StringExpr("short"), golang.IntExpr(1), golang.StructExpr{Type: point}),
+						call(golang.StringExpr("a much longer name"), golang.IntExpr(1000), golang.StructExpr{Type: point}),
+						golang.AssignStmt{
+							Dests: golang.Exprs{golang.Symbol{ID: "origin"}},
+							Srcs: golang.Exprs{golang.StructExpr{
+								Type:   point,
+								Fields: []golang.StructExprField{{ID: "X", Value: golang.IntExpr(1)}, {ID: "Y", Value: golang.IntExpr(2)}},
+							}},
+						},
+						golang.Ident("data").Define(golang.BytesExpr("hello, world")),
+					},
+				},
+			},
+		},
+	}
+
+	text := code.WriteString("\t", func(w *code.Writer) {
+		w.SetMaxWidth(40)
+
+		if err := unit.Write(w); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	expected := `package my_test
+
+func Run(
+	name string,
+	count int,
+	origin point,
+) {
+	Run("short", 1, point{})
+	Run(
+		"a much longer name",
+		1000,
+		point{},
+	)
+	origin = point{X: 1, Y: 2}
+	data := []byte{
+		0x68, 0x65, 0x6c, 0x6c, 0x6f,
+		0x2c, 0x20, 0x77, 0x6f, 0x72,
+		0x6c, 0x64,
+	}
+}
+`
+
+	if text != expected {
+		t.Errorf("unexpected output:\n%s", text)
+	}
+}
+
+func TestUnitCgo(t *testing.T) {
+	unit := golang.Unit{
+		Package: golang.PkgName("my"),
+		Cgo:     "#include <stdlib.h>",
+		Decls: golang.Decls{
+			golang.FuncDecls{
+				{
+					ID:     "Alloc",
+					Params: golang.Params{{ID: "size", Type: golang.Int}},
+					Return: golang.Params{{Type: golang.Symbol{Path: "unsafe", ID: "Pointer"}}},
+					Body: golang.BlockStmt{
+						golang.ReturnStmt{Value: golang.CallExpr{
+							Func: golang.Symbol{Path: "C", ID: "malloc"},
+							Args: golang.Exprs{golang.CallExpr{Func: golang.Symbol{Path: "C", ID: "size_t"}, Args: golang.Exprs{golang.Symbol{ID: "size"}}}},
+						}},
+					},
+				},
+			},
+		},
+	}
+
+	text := code.WriteString("\t", func(w *code.Writer) {
+		if err := unit.Write(w); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	expected := `package my
+
+/*
+#include <stdlib.h>
+*/
+import "C"
+
+import unsafe "unsafe"
+
+func Alloc(size int) unsafe.Pointer {
+	return C.malloc(C.size_t(size))
+}
+`
+
+	if text != expected {
+		t.Fatalf("unexpected output:\n%s", text)
+	}
+
+	parsed, err := golang.ParseFile("my.go", text)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if parsed.Cgo != unit.Cgo {
+		t.Errorf("unexpected cgo preamble %q", parsed.Cgo)
+	}
+}
+
 type (
 	testItem struct {
 		name string
@@ -197,7 +395,1000 @@ var testItems []testItem = []testItem{
 		},
 		text: simpleText,
 	},
+	{
+		name: "Types",
+		gen: func() golang.Unit {
+			return golang.Unit{
+				Prefix:  golang.Comment(" THIS FILE IS AUTOMATICALLY GENERATED; DO NOT EDIT"),
+				Package: golang.PkgName("my_test"),
+				Decls: golang.Decls{
+					golang.TypeDecls{
+						{
+							ID: golang.ID("Worker"),
+							Spec: golang.StructType{
+								Fields: []golang.StructField{
+									{
+										ID:   "Jobs",
+										Type: golang.ChanType{Dir: golang.ChanRecv, Item: golang.String},
+									},
+									{
+										ID:   "Results",
+										Type: golang.ChanType{Dir: golang.ChanSend, Item: golang.Error},
+									},
+									{
+										ID:   "Done",
+										Type: golang.ChanType{Item: golang.StructType{}},
+									},
+									{
+										ID: "Handle",
+										Type: golang.FuncType{
+											Params: golang.Params{{Type: golang.String}},
+											Return: golang.Params{{Type: golang.Error}},
+										},
+									},
+								},
+							},
+						},
+						{
+							ID: golang.ID("Handler"),
+							Spec: golang.FuncType{
+								Params: golang.Params{
+									{ID: "job", Type: golang.String},
+									{ID: "attempt", Type: golang.Int},
+								},
+								Return: golang.Params{
+									{Type: golang.Bool},
+									{Type: golang.Error},
+								},
+							},
+						},
+						{
+							ID: golang.ID("Pipeline"),
+							Spec: golang.ChanType{
+								Item: golang.ChanType{Dir: golang.ChanRecv, Item: golang.Int},
+							},
+						},
+					},
+				},
+			}
+		},
+		text: typesText,
+	},
+	{
+		name: "Stmts",
+		gen: func() golang.Unit {
+			jobs := golang.Symbol{ID: "jobs"}
+			results := golang.Symbol{ID: "results"}
+			quit := golang.Symbol{ID: "quit"}
+			handle := golang.Symbol{ID: "handle"}
+			job := golang.Symbol{ID: "job"}
+			idx := golang.Symbol{ID: "idx"}
+			ok := golang.Symbol{ID: "ok"}
+
+			return golang.Unit{
+				Prefix:  golang.Comment(" THIS FILE IS AUTOMATICALLY GENERATED; DO NOT EDIT"),
+				Package: golang.PkgName("my_test"),
+				Decls: golang.Decls{
+					golang.FuncDecls{
+						{
+							ID: golang.ID("Dispatch"),
+							Params: golang.Params{
+								{ID: "jobs", Type: golang.ChanType{Dir: golang.ChanRecv, Item: golang.String}},
+								{ID: "results", Type: golang.ChanType{Dir: golang.ChanSend, Item: golang.Error}},
+								{ID: "quit", Type: golang.ChanType{Item: golang.StructType{}}},
+								{ID: "handle", Type: golang.FuncType{
+									Params: golang.Params{{Type: golang.String}},
+									Return: golang.Params{{Type: golang.Error}},
+								}},
+							},
+							Body: golang.BlockStmt{
+								golang.RangeStmt{
+									Range: golang.IntExpr(3),
+									Then: golang.BlockStmt{
+										golang.GoStmt{
+											Expr: golang.CallExpr{Func: handle, Args: golang.Exprs{golang.StringExpr("warmup")}},
+										},
+									},
+								},
+								golang.LabeledStmt{
+									Label: "loop",
+									Stmt: golang.ForStmt{
+										Then: golang.BlockStmt{
+											golang.SelectStmt{
+												Cases: []golang.SelectCase{
+													{
+														Comm: golang.AssignStmt{
+															Auto:  true,
+															Dests: golang.Exprs{job, ok},
+															Srcs:  golang.Exprs{golang.RecvExpr{Chan: jobs}},
+														},
+														Stmts: []golang.Stmt{
+															golang.IfStmt{
+																Cond: golang.NotExpr{Op: ok},
+																Then: golang.BlockStmt{golang.BreakStmt{Label: "loop"}},
+															},
+															golang.SendStmt{
+																Chan:  results,
+																Value: golang.CallExpr{Func: handle, Args: golang.Exprs{job}},
+															},
+														},
+													},
+													{
+														Comm: golang.ExprStmt{Expr: golang.RecvExpr{Chan: quit}},
+														Stmts: []golang.Stmt{
+															golang.GotoStmt{Label: "done"},
+														},
+													},
+												},
+											},
+										},
+									},
+								},
+								golang.LabeledStmt{
+									Label: "done",
+									Stmt: golang.RangeStmt{
+										Auto:  true,
+										Key:   idx,
+										Value: job,
+										Range: golang.SliceExpr{
+											Type:  golang.SliceType{Items: golang.String},
+											Items: golang.Exprs{golang.StringExpr("a"), golang.StringExpr("b")},
+										},
+										Then: golang.BlockStmt{
+											golang.SwitchStmt{
+												Cases: []golang.SwitchCase{
+													{
+														Value: golang.EqualExpr{LHS: idx, RHS: golang.IntExpr(0)},
+														Stmts: []golang.Stmt{golang.ContinueStmt{}},
+													},
+													{
+														Stmts: []golang.Stmt{
+															golang.ExprStmt{
+																Expr: golang.CallExpr{Func: handle, Args: golang.Exprs{job}},
+															},
+														},
+													},
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			}
+		},
+		text: stmtsText,
+	},
+	{
+		name: "TypeSwitch",
+		gen: func() golang.Unit {
+			res := golang.Unit{
+				Prefix:  golang.Comment(" THIS FILE IS AUTOMATICALLY GENERATED; DO NOT EDIT"),
+				Package: golang.PkgName("my_test"),
+			}
+
+			stringer := golang.SymbolFor[fmt.Stringer](&res)
+			v := golang.Symbol{ID: "v"}
+			s := golang.Symbol{ID: "s"}
+			x := golang.Symbol{ID: "x"}
+			ok := golang.Symbol{ID: "ok"}
+
+			res.Decls = golang.Decls{
+				golang.FuncDecls{
+					{
+						ID:     golang.ID("Describe"),
+						Params: golang.Params{{ID: "v", Type: golang.Any}},
+						Return: golang.Params{{Type: golang.String}},
+						Body: golang.BlockStmt{
+							golang.IfStmt{
+								Init: golang.AssignStmt{
+									Auto:  true,
+									Dests: golang.Exprs{s, ok},
+									Srcs:  golang.Exprs{golang.TypeAssertExpr{Value: v, Type: stringer}},
+								},
+								Cond: ok,
+								Then: golang.BlockStmt{
+									golang.ReturnStmt{Value: golang.CallExpr{Func: golang.MemberExpr{Value: s, ID: "String"}}},
+								},
+							},
+							golang.TypeSwitchStmt{
+								Bind:  "x",
+								Value: v,
+								Cases: []golang.TypeSwitchCase{
+									{
+										Types: []golang.Type{golang.Nil},
+										Stmts: []golang.Stmt{golang.ReturnStmt{Value: golang.StringExpr("nil")}},
+									},
+									{
+										Types: []golang.Type{golang.Int, golang.Int64},
+										Stmts: []golang.Stmt{golang.ReturnStmt{Value: golang.StringExpr("integer")}},
+									},
+									{
+										Types: []golang.Type{golang.Error},
+										Stmts: []golang.Stmt{golang.ReturnStmt{Value: golang.CallExpr{Func: golang.MemberExpr{Value: x, ID: "Error"}}}},
+									},
+									{
+										Stmts: []golang.Stmt{golang.ReturnStmt{Value: golang.TypeAssertExpr{Value: v, Type: golang.String}}},
+									},
+								},
+							},
+						},
+					},
+				},
+			}
+
+			return res
+		},
+		text: typeSwitchText,
+	},
+	{
+		name: "Assign",
+		gen: func() golang.Unit {
+			values := golang.Symbol{ID: "values"}
+			sum := golang.Symbol{ID: "sum"}
+			flags := golang.Symbol{ID: "flags"}
+			count := golang.Symbol{ID: "count"}
+			total := golang.Symbol{ID: "total"}
+			i := golang.Symbol{ID: "i"}
+
+			return golang.Unit{
+				Prefix:  golang.Comment(" THIS FILE IS AUTOMATICALLY GENERATED; DO NOT EDIT"),
+				Package: golang.PkgName("my_test"),
+				Decls: golang.Decls{
+					golang.ConstDecls{
+						{
+							IDs:    []golang.ID{"KindA", "KindB"},
+							Values: golang.Exprs{golang.Iota, golang.AddExpr{LHS: golang.Iota, RHS: golang.IntExpr(10)}},
+						},
+						{
+							IDs: []golang.ID{"KindC", "KindD"},
+						},
+					},
+					golang.VarDecls{
+						{
+							IDs:  []golang.ID{"width", "height"},
+							Type: golang.Int,
+						},
+						{
+							IDs:    []golang.ID{"left", "right"},
+							Values: golang.Exprs{golang.StringExpr("l"), golang.StringExpr("r")},
+						},
+					},
+					golang.FuncDecls{
+						{
+							ID:     golang.ID("Accumulate"),
+							Params: golang.Params{{ID: "values", Type: golang.SliceType{Items: golang.Int}}},
+							Return: golang.Params{{ID: "sum", Type: golang.Int}, {ID: "flags", Type: golang.Uint}},
+							Body: golang.BlockStmt{
+								golang.VarDecl{
+									IDs:    []golang.ID{"count", "total"},
+									Values: golang.Exprs{golang.IntExpr(0), golang.CallExpr{Func: golang.Symbol{ID: "len"}, Args: golang.Exprs{values}}},
+								},
+								golang.ForStmt{
+									Init: golang.AssignStmt{Auto: true, Dests: golang.Exprs{i}, Srcs: golang.Exprs{golang.IntExpr(0)}},
+									Cond: golang.LessThanExpr{LHS: i, RHS: total},
+									Next: golang.IncDecStmt{Expr: i},
+									Then: golang.BlockStmt{
+										golang.AssignStmt{Op: golang.AssignAdd, Dests: golang.Exprs{sum}, Srcs: golang.Exprs{golang.IndexExpr{Slice: values, Index: i}}},
+										golang.AssignStmt{Op: golang.AssignOr, Dests: golang.Exprs{flags}, Srcs: golang.Exprs{golang.ShiftLeftExpr{LHS: golang.IntExpr(1), RHS: i}}},
+										golang.IncDecStmt{Expr: count},
+									},
+								},
+								golang.AssignStmt{Op: golang.AssignShl, Dests: golang.Exprs{flags}, Srcs: golang.Exprs{golang.IntExpr(1)}},
+								golang.IncDecStmt{Expr: total, Dec: true},
+								golang.AssignStmt{Dests: golang.Exprs{golang.Symbol{ID: golang.Ignore}}, Srcs: golang.Exprs{count}},
+								golang.ReturnStmt{},
+							},
+						},
+					},
+				},
+			}
+		},
+		text: assignText,
+	},
+	{
+		name: "Precedence",
+		gen: func() golang.Unit {
+			a := golang.Symbol{ID: "pa"}
+			b := golang.Symbol{ID: "pb"}
+			c := golang.Symbol{ID: "pc"}
+			sp := golang.Symbol{ID: "psp"}
+
+			define := func(id golang.ID, value golang.Expr) golang.AssignStmt {
+				return golang.AssignStmt{Auto: true, Dests: golang.Exprs{golang.Symbol{ID: id}}, Srcs: golang.Exprs{value}}
+			}
+
+			return golang.Unit{
+				Prefix:  golang.Comment(" THIS FILE IS AUTOMATICALLY GENERATED; DO NOT EDIT"),
+				Package: golang.PkgName("my_test"),
+				Decls: golang.Decls{
+					golang.TypeDecls{
+						{ID: "ppoint", Spec: golang.StructType{Fields: []golang.StructField{{ID: "x", Type: golang.Int}}}},
+					},
+					golang.VarDecls{
+						{
+							IDs:    []golang.ID{"pa", "pb", "pc"},
+							Values: golang.Exprs{golang.IntExpr(1), golang.IntExpr(2), golang.IntExpr(3)},
+						},
+						{
+							ID: "psp",
+							Value: golang.AddrOfExpr{Op: golang.SliceExpr{
+								Type:  golang.SliceType{Items: golang.Int},
+								Items: golang.Exprs{golang.IntExpr(4)},
+							}},
+						},
+					},
+					golang.FuncDecls{
+						{
+							ID:     golang.ID("Compute"),
+							Return: golang.Params{{Type: golang.Int}},
+							Body: golang.BlockStmt{
+								define("x", golang.MultiplyExpr{LHS: golang.AddExpr{LHS: a, RHS: b}, RHS: c}),
+								define("y", golang.SubtractExpr{LHS: a, RHS: golang.SubtractExpr{LHS: b, RHS: c}}),
+								define("z", golang.SubtractExpr{LHS: golang.SubtractExpr{LHS: a, RHS: b}, RHS: c}),
+								define("n", golang.NegateExpr{Op: golang.NegateExpr{Op: a}}),
+								define("m", golang.NegateExpr{Op: golang.IntExpr(-1)}),
+								define("f", golang.BitClearExpr{LHS: golang.BitOrExpr{LHS: a, RHS: b}, RHS: c}),
+								define("e", golang.IndexExpr{Slice: golang.DerefExpr{Op: sp}, Index: golang.IntExpr(0)}),
+								define("ok", golang.LogOrExpr{
+									LHS: golang.NotExpr{Op: golang.LessThanExpr{LHS: a, RHS: b}},
+									RHS: golang.LogAndExpr{
+										LHS: golang.EqualExpr{LHS: a, RHS: b},
+										RHS: golang.MoreThanExpr{LHS: c, RHS: golang.IntExpr(0)},
+									},
+								}),
+								// values used as operands
+								define("v", golang.MultiplyExpr{LHS: golang.V(a).Add(b), RHS: c}),
+								define("w", golang.NegateExpr{Op: golang.V(golang.NegateExpr{Op: a})}),
+								define("nv", golang.NotExpr{Op: golang.V(a).Eq(b)}),
+								// composite literals in statement headers
+								golang.VarDecl{ID: "pt", Type: golang.Symbol{ID: "ppoint"}},
+								golang.IfStmt{
+									Cond: golang.EqualExpr{LHS: golang.Symbol{ID: "pt"}, RHS: golang.StructExpr{Type: golang.Symbol{ID: "ppoint"}}},
+									Then: golang.BlockStmt{golang.ReturnStmt{Value: golang.MemberExpr{Value: golang.Symbol{ID: "pt"}, ID: "x"}}},
+								},
+								golang.IfStmt{
+									Cond: golang.LogAndExpr{LHS: golang.Symbol{ID: "ok"}, RHS: golang.Symbol{ID: "nv"}},
+									Then: golang.BlockStmt{golang.ReturnStmt{Value: golang.IntExpr(0)}},
+								},
+								golang.ReturnStmt{Value: golang.AddExpr{
+									LHS: golang.AddExpr{
+										LHS: golang.AddExpr{
+											LHS: golang.AddExpr{LHS: golang.Symbol{ID: "x"}, RHS: golang.Symbol{ID: "y"}},
+											RHS: golang.AddExpr{LHS: golang.Symbol{ID: "z"}, RHS: golang.Symbol{ID: "n"}},
+										},
+										RHS: golang.MultiplyExpr{
+											LHS: golang.Symbol{ID: "m"},
+											RHS: golang.MultiplyExpr{LHS: golang.Symbol{ID: "f"}, RHS: golang.Symbol{ID: "e"}},
+										},
+									},
+									RHS: golang.MultiplyExpr{LHS: golang.Symbol{ID: "v"}, RHS: golang.Symbol{ID: "w"}},
+								}},
+							},
+						},
+					},
+				},
+			}
+		},
+		text: precedenceText,
+	},
+	{
+		name: "AutoImports",
+		gen: func() golang.Unit {
+			res := golang.Unit{
+				Prefix:  golang.Comment(" THIS FILE IS AUTOMATICALLY GENERATED; DO NOT EDIT"),
+				Package: golang.PkgName("my_test"),
+			}
+
+			// Unused imports are dropped, blank imports are kept.
+			res.Imports.Ensure("", "strings")
+			res.Imports.Ensure(golang.PkgName(golang.Ignore), "embed")
+
+			call := func(path string, id golang.ID, arg golang.Expr) golang.Expr {
+				return golang.CallExpr{Func: golang.Symbol{Path: path, ID: id}, Args: golang.Exprs{arg}}
+			}
+
+			res.Decls = golang.Decls{
+				golang.TypeDecls{
+					{ID: "template", Spec: golang.StructType{}},
+				},
+				golang.VarDecls{
+					{ID: "textTmpl", Value: call("text/template", "New", golang.StringExpr("text"))},
+					{ID: "htmlTmpl", Value: call("html/template", "New", golang.StringExpr("html"))},
+					{ID: "randInt", Value: golang.CallExpr{Func: golang.Symbol{Path: "math/rand", ID: "Int"}}},
+					{ID: "randN", Value: call("math/rand/v2", "N", golang.IntExpr(10))},
+					{ID: "errEmpty", Value: call("errors", "New", golang.StringExpr("empty"))},
+				},
+			}
+
+			return res
+		},
+		text: autoImportsText,
+	},
+	{
+		name: "Regions",
+		gen: func() golang.Unit {
+			return golang.Unit{
+				Prefix:  golang.Comment(" THIS FILE IS AUTOMATICALLY GENERATED; DO NOT EDIT"),
+				Package: golang.PkgName("my_test"),
+				Decls: golang.Decls{
+					golang.TypeDecls{
+						{ID: "Store", Spec: golang.StructType{Fields: []golang.StructField{{ID: "items", Type: golang.SliceType{Items: golang.String}}}}},
+					},
+					golang.MethDecls{
+						{
+							Receiver: golang.Param{ID: "s", Type: golang.PtrType{Item: golang.Symbol{ID: "Store"}}},
+							ID:       "Add",
+							Params:   golang.Params{{ID: "item", Type: golang.String}},
+							Return:   golang.Params{{Type: golang.Error}},
+							Body: golang.BlockStmt{
+								golang.RegionStmt{
+									Name: "Store.Add",
+									Stmts: []golang.Stmt{
+										golang.ReturnStmt{Value: golang.Nil},
+									},
+								},
+							},
+						},
+					},
+					golang.RegionDecl{
+						Name: "helpers",
+						Decls: golang.Decls{
+							golang.FuncDecls{
+								{
+									ID:     "storeSize",
+									Params: golang.Params{{ID: "s", Type: golang.PtrType{Item: golang.Symbol{ID: "Store"}}}},
+									Return: golang.Params{{Type: golang.Int}},
+									Body: golang.BlockStmt{
+										golang.ReturnStmt{Value: golang.CallExpr{
+											Func: golang.Symbol{ID: "len"},
+											Args: golang.Exprs{golang.MemberExpr{Value: golang.Symbol{ID: "s"}, ID: "items"}},
+										}},
+									},
+								},
+							},
+						},
+					},
+				},
+			}
+		},
+		text: regionsText,
+	},
+	{
+		name: "Corpus",
+		gen:  genCorpus,
+		text: corpusText,
+	},
+	{
+		name: "Docs",
+		gen:  genDocs,
+		text: docsText,
+	},
+	{
+		name: "Platform",
+		gen: func() golang.Unit {
+			res := golang.Unit{
+				Generator: "go-code",
+				Prefix:    golang.Comment(" THIS FILE IS AUTOMATICALLY GENERATED; DO NOT EDIT"),
+				Build:     "linux && (amd64 || arm64)",
+				Package:   golang.PkgName("my_test"),
+			}
+
+			res.Imports.Ensure(".", "math")
+			res.Imports.Ensure(golang.PkgName(golang.Ignore), "time/tzdata")
+			res.Imports.Comment("time/tzdata", " Embed the time zone database.")
+
+			res.Decls = golang.Decls{
+				golang.FuncDecls{
+					{
+						ID:     "RoundUp",
+						Params: golang.Params{{ID: "size", Type: golang.Float64}},
+						Return: golang.Params{{Type: golang.Float64}},
+						Body: golang.BlockStmt{
+							golang.ReturnStmt{Value: golang.CallExpr{
+								Func: golang.Symbol{Path: "math", ID: "Ceil"},
+								Args: golang.Exprs{golang.Symbol{ID: "size"}},
+							}},
+						},
+					},
+				},
+			}
+
+			return res
+		},
+		text: platformText,
+	},
+	{
+		name: "Methods",
+		gen:  genMethods,
+		text: methodsText,
+	},
+	{
+		name: "Generics",
+		gen:  genGenerics,
+		text: genericsText,
+	},
+	{
+		name: "Literals",
+		gen:  genLiterals,
+		text: literalsText,
+	},
+}
+
+// genCorpus generates a unit using every node type.
+func genCorpus() golang.Unit {
+	sym := func(id golang.ID) golang.Symbol { return golang.Symbol{ID: id} }
+	define := func(id golang.ID, value golang.Expr) golang.AssignStmt {
+		return golang.AssignStmt{Auto: true, Dests: golang.Exprs{sym(id)}, Srcs: golang.Exprs{value}}
+	}
+	ignore := func(value golang.Expr) golang.Stmt {
+		return golang.AssignStmt{Dests: golang.Exprs{sym(golang.Ignore)}, Srcs: golang.Exprs{value}}
+	}
+
+	record := sym("CorpusRecord")
+	n := golang.DerefExpr{Op: sym("n")}
+	m := sym("m")
+	x := sym("x")
+	res := sym("res")
+
+	return golang.Unit{
+		Prefix:  golang.Comment(" THIS FILE IS AUTOMATICALLY GENERATED; DO NOT EDIT"),
+		Package: golang.PkgName("my_test"),
+		Decls: golang.Decls{
+			golang.ConstDecls{
+				{ID: "CorpusFirst", Type: sym("CorpusKind"), Value: golang.Iota},
+				{ID: "CorpusSecond"},
+			},
+			golang.Comment(" Types"),
+			golang.TypeDecls{
+				{ID: "CorpusKind", Spec: golang.Uint8},
+				{
+					ID: "CorpusNumber",
+					Spec: golang.InterfaceType{
+						Consts: golang.GenConsts{{Tilde: true, Base: golang.Int}, {Tilde: true, Base: golang.Int64}},
+					},
+				},
+				{
+					ID: "CorpusNamed",
+					Spec: golang.InterfaceType{
+						Meths: []golang.InterfaceMeth{
+							{ID: "Name", Return: golang.Params{{Type: golang.String}}},
+							{ID: "Rename", Params: golang.Params{{ID: "name", Type: golang.String}}, Return: golang.Params{{Type: golang.Error}}},
+						},
+					},
+				},
+				{
+					ID:   "CorpusBase",
+					Spec: golang.StructType{Fields: []golang.StructField{{ID: "Key", Type: golang.Int}}},
+				},
+				{
+					Comment: golang.Comment(" CorpusRecord is a record."),
+					ID:      "CorpusRecord",
+					Spec: golang.StructType{
+						Bases: []golang.Type{sym("CorpusBase")},
+						Fields: []golang.StructField{
+							{Comment: golang.Comment(" Name is the record name."), ID: "Name", Type: golang.String, Tags: golang.Tags{{Name: "json", Value: "name"}}},
+							{ID: "Grid", Type: golang.SliceType{Items: golang.Int, Size: golang.IntExpr(4)}},
+							{ID: "Flags", Type: golang.MapType{Key: golang.String, Value: golang.Bool}},
+							{ID: "Next", Type: golang.PtrType{Item: record}},
+						},
+					},
+				},
+				{
+					ID:   "CorpusAlias",
+					Spec: golang.TypeAlias{Target: record},
+				},
+				{
+					ID: "CorpusPair",
+					GenParams: golang.GenParams{
+						{ID: "K", Const: golang.GenConst{Base: golang.Comparable}},
+						{ID: "V", Const: golang.GenConst{Base: golang.Any}},
+					},
+					Spec: golang.StructType{
+						Fields: []golang.StructField{
+							{ID: "Key", Type: sym("K")},
+							{ID: "Value", Type: sym("V")},
+						},
+					},
+				},
+			},
+			golang.MethDecls{
+				{
+					Receiver: golang.Param{ID: "p", Type: golang.Symbol{ID: "CorpusPair", GenArgs: golang.GenArgs{sym("K"), sym("V")}}},
+					ID:       "Get",
+					Return:   golang.Params{{Type: sym("V")}},
+					Body:     golang.BlockStmt{golang.ReturnStmt{Value: golang.MemberExpr{Value: sym("p"), ID: "Value"}}},
+				},
+			},
+			golang.FuncDecls{
+				{
+					ID:        "CorpusSum",
+					GenParams: golang.GenParams{{ID: "T", Const: golang.GenConst{Base: sym("CorpusNumber")}}},
+					Params:    golang.Params{{ID: "values", Type: golang.SliceType{Items: sym("T")}}},
+					Return:    golang.Params{{Type: sym("T")}},
+					Body: golang.BlockStmt{
+						golang.VarDecl{ID: "total", Type: sym("T")},
+						golang.RangeStmt{
+							Auto:  true,
+							Value: sym("v"),
+							Range: sym("values"),
+							Then: golang.BlockStmt{
+								golang.AssignStmt{Op: golang.AssignAdd, Dests: golang.Exprs{sym("total")}, Srcs: golang.Exprs{sym("v")}},
+							},
+						},
+						golang.ReturnStmt{Value: sym("total")},
+					},
+				},
+				{
+					ID:     "CorpusCount",
+					Params: golang.Params{{ID: "values", Type: golang.Int, Variadic: true}},
+					Return: golang.Params{{Type: golang.Int}},
+					Body:   golang.BlockStmt{golang.ReturnStmt{Value: golang.CallExpr{Func: sym("len"), Args: golang.Exprs{sym("values")}}}},
+				},
+				{
+					ID:     "Corpus",
+					Params: golang.Params{{ID: "r", Type: golang.PtrType{Item: record}}},
+					Return: golang.Params{{ID: "res", Type: golang.Int}},
+					Body: golang.BlockStmt{
+						golang.ConstDecl{ID: "limit", Type: golang.Int, Value: golang.IntExpr(10)},
+						golang.TypeDecl{ID: "local", Spec: golang.StructType{}},
+						golang.DeferStmt{Expr: golang.CallExpr{Func: golang.FuncExpr{
+							Body: golang.BlockStmt{golang.IncDecStmt{Expr: res}},
+						}}},
+						define("m", golang.MapExpr{
+							Type:    golang.MapType{Key: golang.String, Value: golang.Int},
+							Entries: []golang.MapEntry{{Key: golang.StringExpr("a"), Value: golang.IntExpr(1)}, {Key: golang.StringExpr("b"), Value: golang.IntExpr(2)}},
+						}),
+						define("p", golang.AddrOfExpr{Op: golang.StructExpr{
+							Type: record,
+							Fields: []golang.StructExprField{
+								{ID: "Name", Value: golang.StringExpr("x")},
+								{ID: "Grid", Value: golang.SliceExpr{
+									Type:  golang.SliceType{Items: golang.Int, Size: golang.IntExpr(4)},
+									Items: golang.Exprs{golang.IntExpr(1), golang.IntExpr(2), golang.IntExpr(3), golang.IntExpr(4)},
+								}},
+								{ID: "Next", Value: golang.Nil},
+							},
+						}}),
+						define("s", golang.MakeExpr{Type: golang.SliceType{Items: golang.Int}, Sizes: golang.Exprs{golang.IntExpr(0), golang.IntExpr(4)}}),
+						golang.AssignStmt{Dests: golang.Exprs{sym("s")}, Srcs: golang.Exprs{golang.RangeExpr{
+							Slice: golang.MemberExpr{Value: sym("p"), ID: "Grid"},
+							Min:   golang.IntExpr(1),
+							Max:   golang.IntExpr(3),
+						}}},
+						define("n", golang.NewExpr{Type: golang.Int}),
+						golang.AssignStmt{Dests: golang.Exprs{n}, Srcs: golang.Exprs{golang.ComplementExpr{Op: golang.IntExpr(3)}}},
+						define("u", golang.CastExpr{Type: golang.Uint64, Value: golang.UintExpr(7)}),
+						define("f", golang.FloatExpr(1.5)),
+						define("g", golang.FloatExpr(1)),
+						define("h", golang.ExpFloatExpr(1e3)),
+						define("c", golang.RuneExpr('x')),
+						define("b", golang.True),
+						golang.IfStmt{
+							Init: define("x", golang.IndexExpr{Slice: m, Index: golang.StringExpr("a")}),
+							Cond: golang.MoreThanExpr{LHS: x, RHS: sym("limit")},
+							Then: golang.BlockStmt{golang.AssignStmt{Dests: golang.Exprs{res}, Srcs: golang.Exprs{x}}},
+							Else: golang.IfStmt{
+								Cond: golang.LessThanExpr{LHS: x, RHS: golang.IntExpr(0)},
+								Then: golang.BlockStmt{golang.AssignStmt{Dests: golang.Exprs{res}, Srcs: golang.Exprs{golang.NegateExpr{Op: x}}}},
+								Else: golang.BlockStmt{golang.AssignStmt{Dests: golang.Exprs{sym("b")}, Srcs: golang.Exprs{golang.False}}},
+							},
+						},
+						golang.ForStmt{
+							Cond: golang.LessThanExpr{LHS: n, RHS: golang.IntExpr(100)},
+							Then: golang.BlockStmt{golang.AssignStmt{Op: golang.AssignMul, Dests: golang.Exprs{n}, Srcs: golang.Exprs{golang.IntExpr(2)}}},
+						},
+						golang.SwitchStmt{
+							Value: golang.IndexExpr{Slice: m, Index: golang.StringExpr("b")},
+							Cases: []golang.SwitchCase{
+								{Value: golang.IntExpr(1), Stmts: []golang.Stmt{golang.FallThroughStmt{}}},
+								{Value: golang.IntExpr(2), Stmts: []golang.Stmt{golang.AssignStmt{Op: golang.AssignAdd, Dests: golang.Exprs{res}, Srcs: golang.Exprs{golang.IntExpr(2)}}}},
+							},
+						},
+						golang.AssignStmt{Op: golang.AssignAdd, Dests: golang.Exprs{res}, Srcs: golang.Exprs{golang.BitXorExpr{
+							LHS: golang.AddExpr{
+								LHS: golang.CallExpr{Func: sym("len"), Args: golang.Exprs{sym("s")}},
+								RHS: golang.AddExpr{
+									LHS: golang.ModulusExpr{LHS: n, RHS: golang.IntExpr(3)},
+									RHS: golang.DivideExpr{LHS: n, RHS: golang.IntExpr(2)},
+								},
+							},
+							RHS: golang.BitAndExpr{LHS: golang.ShiftRightExpr{LHS: n, RHS: golang.IntExpr(1)}, RHS: golang.IntExpr(1)},
+						}}},
+						golang.IfStmt{
+							Cond: golang.LogOrExpr{
+								LHS: golang.LogAndExpr{
+									LHS: golang.NotEqualExpr{LHS: res, RHS: golang.IntExpr(0)},
+									RHS: golang.LessOrEqualExpr{LHS: res, RHS: golang.IntExpr(1)},
+								},
+								RHS: golang.MoreOrEqualExpr{LHS: golang.CastExpr{Type: golang.Uint64, Value: res}, RHS: sym("u")},
+							},
+							Then: golang.BlockStmt{golang.ReturnStmt{}},
+						},
+						ignore(golang.IdentExpr{Op: sym("f")}),
+						ignore(golang.MultiplyExpr{LHS: sym("g"), RHS: sym("h")}),
+						ignore(golang.CallExpr{Func: sym("CorpusCount"), Args: golang.Exprs{sym("s")}, Spread: true}),
+						ignore(sym("c")),
+						golang.AssignStmt{Dests: golang.Exprs{sym("b")}, Srcs: golang.Exprs{golang.NotExpr{Op: sym("b")}}},
+						ignore(sym("b")),
+						ignore(golang.StructExpr{Type: sym("local")}),
+						ignore(golang.ParExpr{Expr: golang.CallExpr{Func: sym("CorpusSum"), Args: golang.Exprs{golang.SliceExpr{
+							Type:  golang.SliceType{Items: golang.Int},
+							Items: golang.Exprs{golang.IntExpr(1), golang.IntExpr(2)},
+						}}}}),
+						ignore(golang.CallExpr{Func: golang.MemberExpr{Value: golang.StructExpr{
+							Type:   golang.Symbol{ID: "CorpusPair", GenArgs: golang.GenArgs{golang.String, golang.Int}},
+							Fields: []golang.StructExprField{{ID: "Key", Value: golang.StringExpr("k")}},
+						}, ID: "Get"}}),
+						ignore(golang.MemberExpr{Value: sym("r"), ID: "Next"}),
+						golang.ReturnStmt{Value: res},
+					},
+				},
+			},
+		},
+	}
+}
+
+func genDocs() golang.Unit {
+	shape := golang.Symbol{ID: "Shape"}
+
+	return golang.Unit{
+		Prefix: golang.Comment(" THIS FILE IS AUTOMATICALLY GENERATED; DO NOT EDIT"),
+		Doc: golang.Doc{
+			Blocks: []golang.DocBlock{
+				golang.DocText("Package my_test shows doc comments."),
+				golang.DocHeading("Usage"),
+				golang.DocText("Shapes are measured by:"),
+				golang.DocList{Items: []string{"their area", "their perimeter,\nwhen closed"}},
+				golang.DocText("Steps:"),
+				golang.DocList{Numbered: true, Items: []string{"create a shape", "measure it"}},
+				golang.DocText("For example:"),
+				golang.DocCode("s := Shape{}\nfmt.Println(s.Area())"),
+			},
+		},
+		Package: golang.PkgName("my_test"),
+		Decls: golang.Decls{
+			golang.Generate("stringer -type=Kind"),
+			golang.BlockComment(" Shapes are immutable. "),
+			golang.TypeDecls{
+				{
+					Doc: golang.Doc{Blocks: []golang.DocBlock{golang.DocText("Shape is a 2D shape; see " + golang.DocLink(golang.Symbol{Path: "image", ID: "Rectangle"}) + ".")}},
+					ID:  "Shape",
+					Spec: golang.StructType{
+						Fields: []golang.StructField{
+							{Doc: golang.Doc{Blocks: []golang.DocBlock{golang.DocText("Width is in [Units].")}}, ID: "Width", Type: golang.Float64},
+							{ID: "Height", Type: golang.Float64},
+						},
+					},
+				},
+				{
+					Doc: golang.Doc{Blocks: []golang.DocBlock{
+						golang.DocText("Measurer measures a [Shape]."),
+						golang.DocLinkDef{Text: "Units", URL: "https://en.wikipedia.org/wiki/SI"},
+					}},
+					ID: "Measurer",
+					Spec: golang.InterfaceType{
+						Meths: []golang.InterfaceMeth{
+							{
+								Doc:    golang.Doc{Blocks: []golang.DocBlock{golang.DocText("Measure returns the area of s.")}},
+								ID:     "Measure",
+								Params: golang.Params{{ID: "s", Type: shape}},
+								Return: golang.Params{{Type: golang.Float64}},
+							},
+						},
+					},
+				},
+			},
+			golang.FuncDecls{
+				{
+					Doc: golang.Doc{
+						Blocks:     []golang.DocBlock{golang.DocText("Area returns the area of s.")},
+						Deprecated: "use a Measurer instead.",
+						Directives: []golang.Directive{{Name: "go:noinline"}},
+					},
+					ID:     "Area",
+					Params: golang.Params{{ID: "s", Type: shape}},
+					Return: golang.Params{{Type: golang.Float64}},
+					Body: golang.BlockStmt{
+						golang.ReturnStmt{Value: golang.MultiplyExpr{
+							LHS: golang.MemberExpr{Value: golang.Symbol{ID: "s"}, ID: "Width"},
+							RHS: golang.MemberExpr{Value: golang.Symbol{ID: "s"}, ID: "Height"},
+						}},
+					},
+				},
+			},
+		},
+	}
+}
+
+func genMethods() golang.Unit {
+	celsius := golang.TypeDecl{
+		ID:   "Celsius",
+		Spec: golang.StructType{Fields: []golang.StructField{{ID: "Value", Type: golang.Float64}}},
+		Implements: []golang.Iface{
+			golang.IfaceOf[fmt.Stringer](),
+			{Type: golang.Symbol{ID: "Setter"}, Meths: []golang.ID{"Set"}},
+		},
+	}
+
+	c := golang.Ident("c")
+
+	celsius.Meths = []golang.MethDecl{
+		golang.Method(celsius.Receiver("c", false), "String").Returns(golang.String).Body(
+			golang.Return(golang.Call(golang.Symbol{Path: "fmt", ID: "Sprintf"}).Args(golang.StringExpr("%.1f°C"), c.Dot("Value"))),
+		),
+		golang.Method(celsius.Receiver("c", true), "Set").Params(golang.Param{ID: "v", Type: golang.Float64}).Body(
+			c.Dot("Value").Assign(golang.Ident("v")),
+		),
+	}
+
+	return golang.Unit{
+		Prefix:  golang.Comment(" THIS FILE IS AUTOMATICALLY GENERATED; DO NOT EDIT"),
+		Package: golang.PkgName("my_test"),
+		Decls: golang.Decls{
+			golang.TypeDecls{
+				{
+					ID: "Setter",
+					Spec: golang.InterfaceType{Meths: []golang.InterfaceMeth{
+						{ID: "Set", Params: golang.Params{{ID: "v", Type: golang.Float64}}},
+					}},
+				},
+			},
+			golang.TypeDecls{
+				celsius,
+				{
+					ID:   "Level",
+					Spec: golang.Uint8,
+					Meths: []golang.MethDecl{
+						golang.Method(golang.Param{ID: "l"}, "String").Returns(golang.String).Body(
+							golang.Return(golang.Call(golang.Symbol{Path: "strconv", ID: "Itoa"}).Args(golang.Call(golang.Int).Args(golang.Ident("l")))),
+						),
+					},
+					Implements: []golang.Iface{golang.IfaceOf[fmt.Stringer]()},
+				},
+			},
+		},
+	}
+}
+
+func genGenerics() golang.Unit {
+	tsym := golang.Symbol{ID: "T"}
+	set := golang.TypeDecl{
+		ID:        "Set",
+		GenParams: golang.GenParams{{ID: "T", Const: golang.GenConst{Base: golang.Comparable}}},
+		Spec:      golang.StructType{Fields: []golang.StructField{{ID: "items", Type: golang.MapType{Key: tsym, Value: golang.Bool}}}},
+	}
+	s, v := golang.Ident("s"), golang.Ident("v")
+
+	set.Meths = []golang.MethDecl{
+		golang.Method(set.Receiver("s", true), "Add").Params(golang.Param{ID: "v", Type: tsym}).Body(
+			s.Dot("items").Index(v).Assign(golang.True),
+		),
+	}
+
+	return golang.Unit{
+		Package: golang.PkgName("my_test"),
+		Decls: golang.Decls{
+			golang.TypeDecls{
+				{
+					ID: "Ordered",
+					Spec: golang.InterfaceType{
+						Consts: golang.GenConsts{{Tilde: true, Base: golang.Int}, {Tilde: true, Base: golang.Float64}, {Tilde: true, Base: golang.String}},
+						Unions: []golang.GenConsts{{{Base: golang.Comparable}}},
+					},
+				},
+				set,
+				{
+					ID:        "Pair",
+					GenParams: golang.GenParams{{ID: "K"}, {ID: "V", Const: golang.GenConst{Base: golang.Any}}},
+					Spec: golang.StructType{Fields: []golang.StructField{
+						{ID: "Key", Type: golang.Symbol{ID: "K"}},
+						{ID: "Value", Type: golang.Symbol{ID: "V"}},
+					}},
+				},
+			},
+			golang.FuncDecls{
+				golang.Func("Largest").
+					GenParams(golang.GenParam{ID: "T", Union: golang.GenConsts{{Tilde: true, Base: golang.Int}, {Tilde: true, Base: golang.Float64}}}).
+					Params(golang.Param{ID: "a"}, golang.Param{ID: "b", Type: tsym}).
+					Returns(tsym).
+					Body(
+						golang.If(golang.Ident("a").MoreThan(golang.Ident("b"))).Then(golang.Return(golang.Ident("a"))),
+						golang.Return(golang.Ident("b")),
+					),
+				golang.Func("LargestInt").
+					Params(golang.Param{ID: "a"}, golang.Param{ID: "b", Type: golang.Int}).
+					Returns(golang.Int).
+					Body(golang.Return(golang.Call(golang.Ident("Largest")).GenArgs(golang.Int).Args(golang.Ident("a"), golang.Ident("b")))),
+				golang.Func("NewPair").
+					Params(golang.Param{ID: "key", Type: golang.String}).
+					Returns(golang.Symbol{ID: "Pair", GenArgs: golang.GenArgs{golang.String, golang.Int}}).
+					Body(golang.Return(golang.Call(golang.Ident("MakePair")).GenArgs(golang.String, golang.Int).Args(golang.Ident("key"), golang.IntExpr(0)))),
+				golang.Func("MakePair").
+					GenParams(golang.GenParam{ID: "K"}, golang.GenParam{ID: "V", Const: golang.GenConst{Base: golang.Any}}).
+					Params(golang.Param{ID: "key", Type: golang.Symbol{ID: "K"}}, golang.Param{ID: "value", Type: golang.Symbol{ID: "V"}}).
+					Returns(golang.Symbol{ID: "Pair", GenArgs: golang.GenArgs{golang.Symbol{ID: "K"}, golang.Symbol{ID: "V"}}}).
+					Body(golang.Return(golang.StructExpr{
+						Type:   golang.Symbol{ID: "Pair", GenArgs: golang.GenArgs{golang.Symbol{ID: "K"}, golang.Symbol{ID: "V"}}},
+						Fields: []golang.StructExprField{{ID: "Key", Value: golang.Ident("key")}, {ID: "Value", Value: golang.Ident("value")}},
+					})),
+			},
+		},
+	}
+}
+
+func genLiterals() golang.Unit {
+	asset := make(golang.BytesExpr, 40)
+	for idx := range asset {
+		asset[idx] = byte(idx * 7)
+	}
+
+	return golang.Unit{
+		Package: golang.PkgName("my_test"),
+		Decls: golang.Decls{
+			golang.ConstDecls{
+				{ID: "ModeMask", Value: golang.UintExpr(0o777).Format(golang.Octal, 4)},
+				{ID: "FlagBits", Value: golang.IntExpr(0b101).Format(golang.Binary, 8)},
+				{ID: "Magic", Value: golang.UintExpr(0xcafe).Format(golang.Hexadecimal, 0)},
+				{ID: "Offset", Value: golang.IntExpr(-16).Format(golang.Hexadecimal, 2)},
+				{ID: "Quoted", Value: golang.RawStringExpr("use `go` here")},
+			},
+			golang.VarDecls{
+				{ID: "selectUsers", Value: golang.RawStringExpr("\nSELECT id, name\nFROM users\nWHERE id = $1\n")},
+			},
+			golang.VarDecls{
+				{ID: "asset", Value: asset},
+				{ID: "header", Value: golang.BytesExpr{0x89, 'P', 'N', 'G'}},
+			},
+			golang.FuncDecls{
+				golang.Func("countUsers").Returns(golang.String).Body(
+					golang.Return(golang.RawStringExpr("SELECT count(*)\n\tFROM users")),
+				),
+			},
+		},
+	}
 }
 
 //go:embed tests/simple_test.go
 var simpleText string
+
+//go:embed tests/types_test.go
+var typesText string
+
+//go:embed tests/stmts_test.go
+var stmtsText string
+
+//go:embed tests/typeswitch_test.go
+var typeSwitchText string
+
+//go:embed tests/assign_test.go
+var assignText string
+
+//go:embed tests/precedence_test.go
+var precedenceText string
+
+//go:embed tests/autoimports_test.go
+var autoImportsText string
+
+//go:embed tests/regions_test.go
+var regionsText string
+
+//go:embed tests/corpus_test.go
+var corpusText string
+
+//go:embed tests/docs_test.go
+var docsText string
+
+//go:embed tests/platform_test.go
+var platformText string
+
+//go:embed tests/methods_test.go
+var methodsText string
+
+//go:embed tests/generics_test.go
+var genericsText string
+
+//go:embed tests/literals_test.go
+var literalsText string
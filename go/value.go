@@ -0,0 +1,450 @@
+package golang
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	code "github.com/trwk76/go-code"
+)
+
+// ValueOf returns the literal expression evaluating to v. Structs, maps, slices, arrays and pointers
+// to them become composite literals, with the zero fields of structs omitted and the keys of maps sorted;
+// time.Time and time.Duration values are written with the time package. The packages of the named types
+// are imported in unit, if not nil, or referenced by path otherwise (see Symbol).
+//
+// Values that cannot be written as literals, such as functions, channels, pointers to basic values and
+// non-zero unexported fields, make the expression fail when written.
+func ValueOf(v any, unit *Unit) Expr {
+	c := valueConv{unit: unit, seen: make(map[uintptr]bool)}
+	return c.value(reflect.ValueOf(v), valueUntyped)
+}
+
+type (
+	valueConv struct {
+		unit *Unit
+		seen map[uintptr]bool
+	}
+
+	// valueCtx tells what is known of the type of the location a value is written into.
+	valueCtx uint8
+
+	// invalidExpr reports, when written, that a value cannot be represented.
+	invalidExpr struct {
+		err error
+	}
+)
+
+const (
+	// valueUntyped: the value must carry its type.
+	valueUntyped valueCtx = iota
+	// valueTyped: the location has the type of the value, so that constants and nil need no conversion.
+	valueTyped
+	// valueElided: the value is an element of a composite literal, so that its composite type may be elided.
+	valueElided
+)
+
+var (
+	timeType     = reflect.TypeFor[time.Time]()
+	durationType = reflect.TypeFor[time.Duration]()
+
+	durationUnits = []struct {
+		id  ID
+		val time.Duration
+	}{
+		{id: "Hour", val: time.Hour},
+		{id: "Minute", val: time.Minute},
+		{id: "Second", val: time.Second},
+		{id: "Millisecond", val: time.Millisecond},
+		{id: "Microsecond", val: time.Microsecond},
+	}
+)
+
+func (c *valueConv) value(v reflect.Value, ctx valueCtx) Expr {
+	if !v.IsValid() {
+		return Nil
+	}
+
+	t := v.Type()
+
+	switch t {
+	case timeType:
+		return c.time(v)
+	case durationType:
+		return c.duration(time.Duration(v.Int()), ctx)
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		return c.basic(t, BoolExpr(v.Bool()), ctx, t == reflect.TypeFor[bool]())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return c.basic(t, IntExpr(v.Int()), ctx, t == reflect.TypeFor[int]())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return c.basic(t, UintExpr(v.Uint()), ctx, false)
+	case reflect.Float32:
+		// the shortest representation of the float32 value
+		val, _ := strconv.ParseFloat(strconv.FormatFloat(v.Float(), 'g', -1, 32), 64)
+		return c.float(t, val, ctx)
+	case reflect.Float64:
+		return c.float(t, v.Float(), ctx)
+	case reflect.String:
+		return c.basic(t, StringExpr(v.String()), ctx, t == reflect.TypeFor[string]())
+	case reflect.Interface:
+		if v.IsNil() {
+			return Nil
+		}
+
+		return c.value(v.Elem(), valueUntyped)
+	case reflect.Pointer:
+		return c.pointer(v, ctx)
+	case reflect.Slice:
+		if v.IsNil() {
+			return c.nilValue(t, ctx)
+		}
+
+		if t == reflect.TypeFor[[]byte]() {
+			return BytesExpr(v.Bytes())
+		}
+
+		return c.slice(v, ctx)
+	case reflect.Array:
+		return c.slice(v, ctx)
+	case reflect.Map:
+		if v.IsNil() {
+			return c.nilValue(t, ctx)
+		}
+
+		return c.mapValue(v, ctx)
+	case reflect.Struct:
+		return c.structValue(v, ctx)
+	}
+
+	return c.invalid("values of type %s cannot be represented", t)
+}
+
+// basic returns the literal lit of type t, converted unless t is the default type of lit (isDefault).
+func (c *valueConv) basic(t reflect.Type, lit Expr, ctx valueCtx, isDefault bool) Expr {
+	if ctx != valueUntyped || isDefault {
+		return lit
+	}
+
+	typ, err := c.typ(t)
+	if err != nil {
+		return invalidExpr{err: err}
+	}
+
+	return CallExpr{Func: typ.(Symbol), Args: Exprs{lit}}
+}
+
+func (c *valueConv) float(t reflect.Type, val float64, ctx valueCtx) Expr {
+	var lit Expr = FloatExpr(val)
+
+	switch {
+	case math.IsNaN(val):
+		lit = CallExpr{Func: c.ref("math", "NaN")}
+	case math.IsInf(val, 0):
+		sign := IntExpr(1)
+		if val < 0 {
+			sign = -1
+		}
+
+		lit = CallExpr{Func: c.ref("math", "Inf"), Args: Exprs{sign}}
+	}
+
+	return c.basic(t, lit, ctx, t == reflect.TypeFor[float64]())
+}
+
+func (c *valueConv) pointer(v reflect.Value, ctx valueCtx) Expr {
+	if v.IsNil() {
+		return c.nilValue(v.Type(), ctx)
+	}
+
+	switch v.Elem().Kind() {
+	case reflect.Struct, reflect.Array, reflect.Slice, reflect.Map:
+	default:
+		return c.invalid("pointers to values of type %s cannot be represented", v.Elem().Type())
+	}
+
+	if v.Elem().Type() == timeType {
+		return c.invalid("pointers to values of type %s cannot be represented", timeType)
+	}
+
+	if c.seen[v.Pointer()] {
+		return c.invalid("cyclic values cannot be represented")
+	}
+
+	c.seen[v.Pointer()] = true
+	defer delete(c.seen, v.Pointer())
+
+	// &T{} is elided as {} within composite literals
+	if ctx == valueElided {
+		return c.value(v.Elem(), valueElided)
+	}
+
+	return AddrOfExpr{Op: c.value(v.Elem(), valueTyped)}
+}
+
+func (c *valueConv) nilValue(t reflect.Type, ctx valueCtx) Expr {
+	if ctx != valueUntyped {
+		return Nil
+	}
+
+	typ, err := c.typ(t)
+	if err != nil {
+		return invalidExpr{err: err}
+	}
+
+	return CastExpr{Type: typ, Value: Nil}
+}
+
+func (c *valueConv) slice(v reflect.Value, ctx valueCtx) Expr {
+	res := SliceExpr{}
+
+	if ctx != valueElided {
+		typ, err := c.typ(v.Type())
+		if err != nil {
+			return invalidExpr{err: err}
+		}
+
+		res.Type = typ
+	}
+
+	for idx := range v.Len() {
+		res.Items = append(res.Items, c.value(v.Index(idx), valueElided))
+	}
+
+	return res
+}
+
+func (c *valueConv) mapValue(v reflect.Value, ctx valueCtx) Expr {
+	res := MapExpr{}
+
+	if ctx != valueElided {
+		typ, err := c.typ(v.Type())
+		if err != nil {
+			return invalidExpr{err: err}
+		}
+
+		res.Type = typ
+	}
+
+	keys := v.MapKeys()
+	slices.SortFunc(keys, compareValues)
+
+	for _, key := range keys {
+		res.Entries = append(res.Entries, MapEntry{
+			Key:   c.value(key, valueElided),
+			Value: c.value(v.MapIndex(key), valueElided),
+		})
+	}
+
+	return res
+}
+
+func (c *valueConv) structValue(v reflect.Value, ctx valueCtx) Expr {
+	t := v.Type()
+	res := StructExpr{}
+
+	if ctx != valueElided {
+		typ, err := c.typ(t)
+		if err != nil {
+			return invalidExpr{err: err}
+		}
+
+		res.Type = typ
+	}
+
+	for idx := range t.NumField() {
+		fld := t.Field(idx)
+		val := v.Field(idx)
+
+		if val.IsZero() {
+			continue
+		}
+
+		if !fld.IsExported() {
+			return c.invalid("unexported field '%s' of %s cannot be represented", fld.Name, t)
+		}
+
+		res.Fields = append(res.Fields, StructExprField{ID: ID(fld.Name), Value: c.value(val, valueTyped)})
+	}
+
+	return res
+}
+
+func (c *valueConv) time(v reflect.Value) Expr {
+	if !v.CanInterface() {
+		return c.invalid("unexported values of type %s cannot be represented", timeType)
+	}
+
+	val := v.Interface().(time.Time)
+
+	if val.IsZero() {
+		return StructExpr{Type: c.ref("time", "Time")}
+	}
+
+	var loc Expr
+
+	switch val.Location() {
+	case time.UTC:
+		loc = c.ref("time", "UTC")
+	case time.Local:
+		loc = c.ref("time", "Local")
+	default:
+		name, offset := val.Zone()
+		loc = CallExpr{Func: c.ref("time", "FixedZone"), Args: Exprs{StringExpr(name), IntExpr(offset)}}
+	}
+
+	return CallExpr{
+		Func: c.ref("time", "Date"),
+		Args: Exprs{
+			IntExpr(val.Year()),
+			c.ref("time", ID(val.Month().String())),
+			IntExpr(val.Day()),
+			IntExpr(val.Hour()),
+			IntExpr(val.Minute()),
+			IntExpr(val.Second()),
+			IntExpr(val.Nanosecond()),
+			loc,
+		},
+	}
+}
+
+// duration writes d as a multiple of the largest unit it is a multiple of, as in 90 * time.Second.
+func (c *valueConv) duration(d time.Duration, ctx valueCtx) Expr {
+	if d == 0 {
+		return c.basic(durationType, IntExpr(0), ctx, false)
+	}
+
+	for _, unit := range durationUnits {
+		if d%unit.val != 0 {
+			continue
+		}
+
+		if d == unit.val {
+			return c.ref("time", unit.id)
+		}
+
+		return MultiplyExpr{LHS: IntExpr(d / unit.val), RHS: c.ref("time", unit.id)}
+	}
+
+	return c.basic(durationType, IntExpr(d), ctx, false)
+}
+
+// typ returns the type t, where named types are symbols.
+func (c *valueConv) typ(t reflect.Type) (Type, error) {
+	if t.Name() != "" {
+		if strings.ContainsRune(t.Name(), '[') {
+			return nil, fmt.Errorf("instantiated generic type %s cannot be represented", t)
+		}
+
+		sym := symbolOf(t)
+		return c.ref(sym.Path, sym.ID), nil
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		item, err := c.typ(t.Elem())
+		return PtrType{Item: item}, err
+	case reflect.Slice:
+		items, err := c.typ(t.Elem())
+		return SliceType{Items: items}, err
+	case reflect.Array:
+		items, err := c.typ(t.Elem())
+		return SliceType{Items: items, Size: IntExpr(t.Len())}, err
+	case reflect.Map:
+		key, err := c.typ(t.Key())
+		if err != nil {
+			return nil, err
+		}
+
+		val, err := c.typ(t.Elem())
+		return MapType{Key: key, Value: val}, err
+	case reflect.Struct:
+		return c.structType(t)
+	case reflect.Interface:
+		if t.NumMethod() < 1 {
+			return Any, nil
+		}
+	}
+
+	return nil, fmt.Errorf("type %s cannot be represented", t)
+}
+
+func (c *valueConv) structType(t reflect.Type) (Type, error) {
+	res := StructType{}
+
+	for idx := range t.NumField() {
+		fld := t.Field(idx)
+
+		typ, err := c.typ(fld.Type)
+		if err != nil {
+			return nil, err
+		}
+
+		if fld.Anonymous {
+			res.Bases = append(res.Bases, typ)
+			continue
+		}
+
+		tags, ok := parseTags(string(fld.Tag))
+		if !ok {
+			return nil, fmt.Errorf("struct tag of field '%s' of %s cannot be represented", fld.Name, t)
+		}
+
+		res.Fields = append(res.Fields, StructField{ID: ID(fld.Name), Type: typ, Tags: tags})
+	}
+
+	return res, nil
+}
+
+// ref returns the symbol id of the package path, imported in the unit if any.
+func (c *valueConv) ref(path string, id ID) Symbol {
+	if path == "" || c.unit == nil {
+		return Symbol{Path: path, ID: id}
+	}
+
+	pkg := c.unit.Imports.Ensure("", path)
+	return Symbol{Package: &pkg, ID: id}
+}
+
+func (c *valueConv) invalid(format string, args ...any) Expr {
+	return invalidExpr{err: fmt.Errorf(format, args...)}
+}
+
+// compareValues orders map keys; keys of other kinds than basic ones are ordered by their text.
+func compareValues(a, b reflect.Value) int {
+	if a.Kind() == b.Kind() {
+		switch a.Kind() {
+		case reflect.Bool:
+			return cmp.Compare(strconv.FormatBool(a.Bool()), strconv.FormatBool(b.Bool()))
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return cmp.Compare(a.Int(), b.Int())
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			return cmp.Compare(a.Uint(), b.Uint())
+		case reflect.Float32, reflect.Float64:
+			return cmp.Compare(a.Float(), b.Float())
+		case reflect.String:
+			return cmp.Compare(a.String(), b.String())
+		}
+	}
+
+	return cmp.Compare(fmt.Sprintf("%#v", a), fmt.Sprintf("%#v", b))
+}
+
+func (e invalidExpr) simpleExpr() bool {
+	return true
+}
+
+func (e invalidExpr) writeExpr(w *code.Writer, singleLine bool) {
+	w.Fail(e.err)
+}
+
+var (
+	_ Expr = invalidExpr{}
+)
@@ -0,0 +1,113 @@
+package golang_test
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	code "github.com/trwk76/go-code"
+	golang "github.com/trwk76/go-code/go"
+)
+
+type (
+	ValueLevel uint8
+
+	ValueLimits struct {
+		Rate  float64
+		Burst int32
+	}
+
+	ValueConfig struct {
+		Name     string
+		Level    ValueLevel
+		Timeout  time.Duration
+		Since    time.Time
+		Limits   *ValueLimits
+		Routes   map[string][]int
+		Backends []*url.URL
+		Extra    any
+		Unset    []string
+	}
+)
+
+func TestValueOf(t *testing.T) {
+	unit := golang.Unit{Package: "my"}
+
+	cfg := ValueConfig{
+		Name:    "api",
+		Level:   3,
+		Timeout: 90 * time.Second,
+		Since:   time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC),
+		Limits:  &ValueLimits{Rate: 1.5, Burst: 10},
+		Routes:  map[string][]int{"users": {1, 2}, "groups": nil, "admin": {3}},
+		Backends: []*url.URL{
+			{Scheme: "https", Host: "a.example.com"},
+		},
+		Extra: []any{ValueLevel(1), 2.0, "x", nil},
+	}
+
+	unit.Decls = golang.Decls{
+		golang.VarDecls{
+			{ID: "config", Value: golang.ValueOf(cfg, &unit)},
+			{ID: "levels", Value: golang.ValueOf([2]ValueLevel{1, 2}, &unit)},
+			{ID: "ratio", Value: golang.ValueOf(float32(0.1), &unit)},
+			{ID: "none", Value: golang.ValueOf((*ValueLimits)(nil), &unit)},
+		},
+	}
+
+	text := code.WriteString("\t", func(w *code.Writer) {
+		if err := unit.Write(w); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	if text != `package my
+
+import (
+	url "net/url"
+	time "time"
+
+	go_test "github.com/trwk76/go-code/go_test"
+)
+
+var (
+	config = go_test.ValueConfig{
+		Name:    "api",
+		Level:   3,
+		Timeout: 90 * time.Second,
+		Since:   time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC),
+		Limits: &go_test.ValueLimits{
+			Rate:  1.5,
+			Burst: 10,
+		},
+		Routes: map[string][]int{
+			"admin":  {3},
+			"groups": nil,
+			"users":  {1, 2},
+		},
+		Backends: []*url.URL{
+			{
+				Scheme: "https",
+				Host:   "a.example.com",
+			},
+		},
+		Extra: []any{go_test.ValueLevel(1), 2.0, "x", nil},
+	}
+
+	levels = [2]go_test.ValueLevel{1, 2}
+	ratio  = float32(0.1)
+	none   = (*go_test.ValueLimits)(nil)
+)
+` {
+		t.Errorf("unexpected output:\n%s", text)
+	}
+
+	unit = golang.Unit{Package: "my", Decls: golang.Decls{golang.VarDecls{{ID: "fn", Value: golang.ValueOf(TestValueOf, nil)}}}}
+
+	w := code.NewWriter(&strings.Builder{}, "\t")
+
+	if err := unit.Write(&w); err == nil {
+		t.Error("expected functions to fail when written")
+	}
+}
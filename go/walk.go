@@ -0,0 +1,529 @@
+package golang
+
+import (
+	"fmt"
+	"reflect"
+)
+
+type (
+	// Node is a node of the AST: a Unit, an Expr, a Stmt, a Type, a TypeSpec, a Decl, a DocBlock, a list
+	// such as Decls, Exprs, Params, GenParams or GenArgs, or a part of a node such as Param, GenParam,
+	// GenConst, MapEntry, StructExprField, SwitchCase, SelectCase, TypeSwitchCase, InterfaceMeth,
+	// StructField, Iface or Doc. Nil interfaces, empty lists, empty comments and empty docs are not
+	// visited, nor are IDs.
+	Node any
+
+	// Visitor visits the nodes encountered by Walk.
+	Visitor interface {
+		// Visit is called for each node; the children of node are then visited with the returned visitor,
+		// unless it is nil, and Visit(nil) is called on it after the children.
+		Visit(node Node) Visitor
+	}
+
+	// RewriteFunc returns the replacement of n, and whether n is replaced.
+	RewriteFunc func(n Node) (Node, bool)
+
+	inspector func(Node) bool
+
+	// children applies fn to the children of a node, rebuilding the node when a child is replaced.
+	children struct {
+		fn      RewriteFunc
+		changed bool
+	}
+)
+
+// Walk visits node and its children, depth-first, in the order they are written.
+func Walk(v Visitor, node Node) {
+	if v = v.Visit(node); v == nil {
+		return
+	}
+
+	rewriteChildren(node, func(n Node) (Node, bool) {
+		Walk(v, n)
+		return n, false
+	})
+
+	v.Visit(nil)
+}
+
+// Inspect visits node and its children, depth-first, calling f for each node; the children of a node
+// are skipped when f returns false. f is called with nil after the children of a node.
+func Inspect(node Node, f func(Node) bool) {
+	Walk(inspector(f), node)
+}
+
+// Rewrite returns node where the nodes for which f reports a replacement are replaced, children first.
+// The nodes are values: node itself is not modified, and the lists without replaced items are shared
+// between node and the result. Rewrite panics if a replacement does not fit where the node was.
+func Rewrite[N Node](node N, f RewriteFunc) N {
+	res, _ := rewrite(node, f)
+	if res == nil {
+		var zero N
+		return zero
+	}
+
+	return res.(N)
+}
+
+func rewrite(node Node, f RewriteFunc) (Node, bool) {
+	node, changed := rewriteChildren(node, func(n Node) (Node, bool) { return rewrite(n, f) })
+
+	if res, ok := f(node); ok {
+		return res, true
+	}
+
+	return node, changed
+}
+
+func (f inspector) Visit(node Node) Visitor {
+	if f(node) {
+		return f
+	}
+
+	return nil
+}
+
+// rewriteChildren returns node, where each child is replaced by the result of fn if it reports so.
+func rewriteChildren(node Node, fn RewriteFunc) (Node, bool) {
+	c := &children{fn: fn}
+
+	switch n := node.(type) {
+	case Unit:
+		n.Prefix = child(c, n.Prefix)
+		n.Doc = child(c, n.Doc)
+		n.Decls = child(c, n.Decls)
+		node = n
+
+	// lists
+	case Decls:
+		node = each(c, n)
+	case Exprs:
+		node = each(c, n)
+	case BlockStmt:
+		node = each(c, n)
+	case Params:
+		node = each(c, n)
+	case GenParams:
+		node = each(c, n)
+	case GenArgs:
+		node = each(c, n)
+	case GenConsts:
+		node = each(c, n)
+	case ConstDecls:
+		node = each(c, n)
+	case FuncDecls:
+		node = each(c, n)
+	case MethDecls:
+		node = each(c, n)
+	case TypeDecls:
+		node = each(c, n)
+	case VarDecls:
+		node = each(c, n)
+
+	// declarations
+	case ConstDecl:
+		n.Comment = child(c, n.Comment)
+		n.Doc = child(c, n.Doc)
+		n.Type = child(c, n.Type)
+		n.Value = child(c, n.Value)
+		n.Values = child(c, n.Values)
+		node = n
+	case VarDecl:
+		n.Comment = child(c, n.Comment)
+		n.Doc = child(c, n.Doc)
+		n.Type = child(c, n.Type)
+		n.Value = child(c, n.Value)
+		n.Values = child(c, n.Values)
+		node = n
+	case FuncDecl:
+		n.Comment = child(c, n.Comment)
+		n.Doc = child(c, n.Doc)
+		n.GenParams = child(c, n.GenParams)
+		n.Params = child(c, n.Params)
+		n.Return = child(c, n.Return)
+		n.Body = child(c, n.Body)
+		node = n
+	case MethDecl:
+		n.Comment = child(c, n.Comment)
+		n.Doc = child(c, n.Doc)
+		n.Receiver = child(c, n.Receiver)
+		n.Params = child(c, n.Params)
+		n.Return = child(c, n.Return)
+		n.Body = child(c, n.Body)
+		node = n
+	case TypeDecl:
+		n.Comment = child(c, n.Comment)
+		n.Doc = child(c, n.Doc)
+		n.GenParams = child(c, n.GenParams)
+		n.Spec = child(c, n.Spec)
+		n.Meths = each(c, n.Meths)
+		n.Implements = each(c, n.Implements)
+		node = n
+	case RegionDecl:
+		n.Decls = child(c, n.Decls)
+		node = n
+	case TypeAlias:
+		n.Target = child(c, n.Target)
+		node = n
+	case Iface:
+		n.Type = child(c, n.Type)
+		node = n
+	case Param:
+		n.Type = child(c, n.Type)
+		node = n
+	case GenParam:
+		n.Const = child(c, n.Const)
+		n.Union = child(c, n.Union)
+		node = n
+	case GenConst:
+		n.Base = child(c, n.Base)
+		node = n
+	case Doc:
+		n.Blocks = each(c, n.Blocks)
+		n.Directives = each(c, n.Directives)
+		node = n
+
+	// types
+	case Symbol:
+		n.GenArgs = child(c, n.GenArgs)
+		node = n
+	case PtrType:
+		n.Item = child(c, n.Item)
+		node = n
+	case SliceType:
+		n.Size = child(c, n.Size)
+		n.Items = child(c, n.Items)
+		node = n
+	case MapType:
+		n.Key = child(c, n.Key)
+		n.Value = child(c, n.Value)
+		node = n
+	case ChanType:
+		n.Item = child(c, n.Item)
+		node = n
+	case FuncType:
+		n.Params = child(c, n.Params)
+		n.Return = child(c, n.Return)
+		node = n
+	case InterfaceType:
+		n.Consts = child(c, n.Consts)
+		n.Unions = each(c, n.Unions)
+		n.Meths = each(c, n.Meths)
+		node = n
+	case InterfaceMeth:
+		n.Comment = child(c, n.Comment)
+		n.Doc = child(c, n.Doc)
+		n.Params = child(c, n.Params)
+		n.Return = child(c, n.Return)
+		node = n
+	case StructType:
+		n.Bases = each(c, n.Bases)
+		n.Fields = each(c, n.Fields)
+		node = n
+	case StructField:
+		n.Comment = child(c, n.Comment)
+		n.Doc = child(c, n.Doc)
+		n.Type = child(c, n.Type)
+		node = n
+
+	// expressions
+	case Value:
+		n.expr = child(c, n.expr)
+		node = n
+	case IntFormatExpr:
+		n.Value = child(c, n.Value)
+		node = n
+	case ParExpr:
+		n.Expr = child(c, n.Expr)
+		node = n
+	case CastExpr:
+		n.Type = child(c, n.Type)
+		n.Value = child(c, n.Value)
+		node = n
+	case SliceExpr:
+		n.Type = child(c, n.Type)
+		n.Items = child(c, n.Items)
+		node = n
+	case MapExpr:
+		n.Type = child(c, n.Type)
+		n.Entries = each(c, n.Entries)
+		node = n
+	case MapEntry:
+		n.Key = child(c, n.Key)
+		n.Value = child(c, n.Value)
+		node = n
+	case StructExpr:
+		n.Type = child(c, n.Type)
+		n.Fields = each(c, n.Fields)
+		node = n
+	case StructExprField:
+		n.Value = child(c, n.Value)
+		node = n
+	case FuncExpr:
+		n.Params = child(c, n.Params)
+		n.Return = child(c, n.Return)
+		n.Body = child(c, n.Body)
+		node = n
+	case NewExpr:
+		n.Type = child(c, n.Type)
+		node = n
+	case MakeExpr:
+		n.Type = child(c, n.Type)
+		n.Sizes = child(c, n.Sizes)
+		node = n
+	case MemberExpr:
+		n.Value = child(c, n.Value)
+		node = n
+	case CallExpr:
+		n.Func = child(c, n.Func)
+		n.GenArgs = child(c, n.GenArgs)
+		n.Args = child(c, n.Args)
+		node = n
+	case TypeAssertExpr:
+		n.Value = child(c, n.Value)
+		n.Type = child(c, n.Type)
+		node = n
+	case IndexExpr:
+		n.Slice = child(c, n.Slice)
+		n.Index = child(c, n.Index)
+		node = n
+	case RangeExpr:
+		n.Slice = child(c, n.Slice)
+		n.Min = child(c, n.Min)
+		n.Max = child(c, n.Max)
+		node = n
+	case IdentExpr:
+		n.Op = child(c, n.Op)
+		node = n
+	case NegateExpr:
+		n.Op = child(c, n.Op)
+		node = n
+	case NotExpr:
+		n.Op = child(c, n.Op)
+		node = n
+	case ComplementExpr:
+		n.Op = child(c, n.Op)
+		node = n
+	case AddrOfExpr:
+		n.Op = child(c, n.Op)
+		node = n
+	case DerefExpr:
+		n.Op = child(c, n.Op)
+		node = n
+	case RecvExpr:
+		n.Chan = child(c, n.Chan)
+		node = n
+	case AddExpr:
+		n.LHS, n.RHS = child(c, n.LHS), child(c, n.RHS)
+		node = n
+	case SubtractExpr:
+		n.LHS, n.RHS = child(c, n.LHS), child(c, n.RHS)
+		node = n
+	case MultiplyExpr:
+		n.LHS, n.RHS = child(c, n.LHS), child(c, n.RHS)
+		node = n
+	case DivideExpr:
+		n.LHS, n.RHS = child(c, n.LHS), child(c, n.RHS)
+		node = n
+	case ModulusExpr:
+		n.LHS, n.RHS = child(c, n.LHS), child(c, n.RHS)
+		node = n
+	case ShiftLeftExpr:
+		n.LHS, n.RHS = child(c, n.LHS), child(c, n.RHS)
+		node = n
+	case ShiftRightExpr:
+		n.LHS, n.RHS = child(c, n.LHS), child(c, n.RHS)
+		node = n
+	case EqualExpr:
+		n.LHS, n.RHS = child(c, n.LHS), child(c, n.RHS)
+		node = n
+	case NotEqualExpr:
+		n.LHS, n.RHS = child(c, n.LHS), child(c, n.RHS)
+		node = n
+	case LessThanExpr:
+		n.LHS, n.RHS = child(c, n.LHS), child(c, n.RHS)
+		node = n
+	case LessOrEqualExpr:
+		n.LHS, n.RHS = child(c, n.LHS), child(c, n.RHS)
+		node = n
+	case MoreThanExpr:
+		n.LHS, n.RHS = child(c, n.LHS), child(c, n.RHS)
+		node = n
+	case MoreOrEqualExpr:
+		n.LHS, n.RHS = child(c, n.LHS), child(c, n.RHS)
+		node = n
+	case BitAndExpr:
+		n.LHS, n.RHS = child(c, n.LHS), child(c, n.RHS)
+		node = n
+	case BitClearExpr:
+		n.LHS, n.RHS = child(c, n.LHS), child(c, n.RHS)
+		node = n
+	case BitXorExpr:
+		n.LHS, n.RHS = child(c, n.LHS), child(c, n.RHS)
+		node = n
+	case BitOrExpr:
+		n.LHS, n.RHS = child(c, n.LHS), child(c, n.RHS)
+		node = n
+	case LogAndExpr:
+		n.LHS, n.RHS = child(c, n.LHS), child(c, n.RHS)
+		node = n
+	case LogOrExpr:
+		n.LHS, n.RHS = child(c, n.LHS), child(c, n.RHS)
+		node = n
+
+	// statements
+	case IfBuilder:
+		n.stmt = child(c, n.stmt)
+		node = n
+	case AssignStmt:
+		n.Dests = child(c, n.Dests)
+		n.Srcs = child(c, n.Srcs)
+		node = n
+	case DeferStmt:
+		n.Expr = child(c, n.Expr)
+		node = n
+	case ExprStmt:
+		n.Expr = child(c, n.Expr)
+		node = n
+	case ForStmt:
+		n.Init = child(c, n.Init)
+		n.Cond = child(c, n.Cond)
+		n.Next = child(c, n.Next)
+		n.Then = child(c, n.Then)
+		node = n
+	case GoStmt:
+		n.Expr = child(c, n.Expr)
+		node = n
+	case IfStmt:
+		n.Init = child(c, n.Init)
+		n.Cond = child(c, n.Cond)
+		n.Then = child(c, n.Then)
+		n.Else = child(c, n.Else)
+		node = n
+	case IncDecStmt:
+		n.Expr = child(c, n.Expr)
+		node = n
+	case LabeledStmt:
+		n.Stmt = child(c, n.Stmt)
+		node = n
+	case RangeStmt:
+		n.Key = child(c, n.Key)
+		n.Value = child(c, n.Value)
+		n.Range = child(c, n.Range)
+		n.Then = child(c, n.Then)
+		node = n
+	case RegionStmt:
+		n.Stmts = each(c, n.Stmts)
+		node = n
+	case ReturnStmt:
+		n.Value = child(c, n.Value)
+		n.Values = child(c, n.Values)
+		node = n
+	case SelectStmt:
+		n.Cases = each(c, n.Cases)
+		node = n
+	case SelectCase:
+		n.Comm = child(c, n.Comm)
+		n.Stmts = each(c, n.Stmts)
+		node = n
+	case SendStmt:
+		n.Chan = child(c, n.Chan)
+		n.Value = child(c, n.Value)
+		node = n
+	case SwitchStmt:
+		n.Init = child(c, n.Init)
+		n.Value = child(c, n.Value)
+		n.Cases = each(c, n.Cases)
+		node = n
+	case SwitchCase:
+		n.Value = child(c, n.Value)
+		n.Stmts = each(c, n.Stmts)
+		node = n
+	case TypeSwitchStmt:
+		n.Init = child(c, n.Init)
+		n.Value = child(c, n.Value)
+		n.Cases = each(c, n.Cases)
+		node = n
+	case TypeSwitchCase:
+		n.Types = each(c, n.Types)
+		n.Stmts = each(c, n.Stmts)
+		node = n
+	}
+
+	return node, c.changed
+}
+
+// child applies fn to n, unless n is empty, and returns its replacement.
+func child[T any](c *children, n T) T {
+	if emptyNode(n) {
+		return n
+	}
+
+	res, ok := c.fn(n)
+	if !ok {
+		return n
+	}
+
+	c.changed = true
+
+	if res == nil {
+		var zero T
+		return zero
+	}
+
+	typed, ok := res.(T)
+	if !ok {
+		panic(fmt.Errorf("cannot replace %T by %T", n, res))
+	}
+
+	return typed
+}
+
+// each applies fn to the items of list, and returns the list of their replacements; list is returned
+// when no item is replaced.
+func each[L ~[]T, T any](c *children, list L) L {
+	var res L
+
+	for idx, itm := range list {
+		before := c.changed
+		c.changed = false
+
+		repl := child(c, itm)
+
+		if c.changed && res == nil {
+			res = make(L, len(list))
+			copy(res, list)
+		}
+
+		if res != nil {
+			res[idx] = repl
+		}
+
+		c.changed = c.changed || before
+	}
+
+	if res == nil {
+		return list
+	}
+
+	return res
+}
+
+func emptyNode(n any) bool {
+	switch n := n.(type) {
+	case nil:
+		return true
+	case Comment:
+		return n == ""
+	case Doc:
+		return n.empty()
+	case GenConst:
+		return !n.Tilde && n.Base == nil
+	}
+
+	if val := reflect.ValueOf(n); val.Kind() == reflect.Slice {
+		return val.Len() < 1
+	}
+
+	return false
+}
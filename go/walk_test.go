@@ -0,0 +1,121 @@
+package golang_test
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+
+	code "github.com/trwk76/go-code"
+	golang "github.com/trwk76/go-code/go"
+)
+
+func TestRewrite(t *testing.T) {
+	load := golang.Symbol{ID: "load"}
+	unit := golang.Unit{
+		Package: "my",
+		Decls: golang.Decls{
+			golang.FuncDecls{
+				golang.Func("Run").Params(golang.Param{ID: "name", Type: golang.String}).Returns(golang.Error).Body(
+					golang.If(golang.Call(load).Args(golang.Ident("name")).Eq(golang.Nil)).Then(
+						golang.Return(golang.Call(golang.Symbol{Path: "errors", ID: "New"}).Args(golang.StringExpr("not found"))),
+					),
+					golang.Return(golang.Nil),
+				),
+			},
+		},
+	}
+
+	var paths []string
+
+	golang.Inspect(unit, func(n golang.Node) bool {
+		if sym, ok := n.(golang.Symbol); ok && sym.Path != "" && !slices.Contains(paths, sym.Path) {
+			paths = append(paths, sym.Path)
+		}
+
+		return true
+	})
+
+	if len(paths) != 1 || paths[0] != "errors" {
+		t.Errorf("unexpected referenced packages %v", paths)
+	}
+
+	logCall := golang.Call(golang.Symbol{Path: "log", ID: "Println"}).Args(golang.StringExpr("enter")).Stmt()
+
+	rename := func(n golang.Node) (golang.Node, bool) {
+		if sym, ok := n.(golang.Symbol); ok && sym.ID == "load" {
+			sym.ID = "lookup"
+			return sym, true
+		}
+
+		return nil, false
+	}
+
+	// the builder wrappers are rewritten as well
+	if val := golang.Rewrite(golang.Ident("load").Call().Not(), rename); !reflect.DeepEqual(val, golang.Ident("lookup").Call().Not()) {
+		t.Errorf("unexpected value: %#v", val)
+	}
+
+	if stmt := golang.Rewrite(golang.If(golang.Ident("load").Call()), rename); !reflect.DeepEqual(stmt, golang.If(golang.Ident("lookup").Call())) {
+		t.Errorf("unexpected statement: %#v", stmt)
+	}
+
+	res := golang.Rewrite(unit, func(n golang.Node) (golang.Node, bool) {
+		if repl, ok := rename(n); ok {
+			return repl, true
+		}
+
+		switch n := n.(type) {
+		case golang.FuncDecl:
+			n.Body = append(golang.BlockStmt{logCall}, n.Body...)
+			return n, true
+		}
+
+		return nil, false
+	})
+
+	write := func(u golang.Unit) string {
+		return code.WriteString("\t", func(w *code.Writer) {
+			if err := u.Write(w); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+
+	if text := write(res); text != `package my
+
+import (
+	errors "errors"
+	log "log"
+)
+
+func Run(name string) error {
+	log.Println("enter")
+	if lookup(name) == nil {
+		return errors.New("not found")
+	}
+	return nil
+}
+` {
+		t.Errorf("unexpected output:\n%s", text)
+	}
+
+	// the original unit is left unchanged
+	if text := write(unit); text != `package my
+
+import errors "errors"
+
+func Run(name string) error {
+	if load(name) == nil {
+		return errors.New("not found")
+	}
+	return nil
+}
+` {
+		t.Errorf("unexpected output:\n%s", text)
+	}
+
+	same := golang.Rewrite(unit.Decls, func(n golang.Node) (golang.Node, bool) { return nil, false })
+	if &same[0] != &unit.Decls[0] {
+		t.Error("expected unchanged lists to be shared")
+	}
+}
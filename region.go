@@ -0,0 +1,239 @@
+package code
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// DefaultMarkers delimit regions with Go/C style line comments.
+var DefaultMarkers = Markers{
+	Begin: "// user code begin: ",
+	End:   "// user code end: ",
+}
+
+type (
+	// Markers are the line prefixes delimiting a region; they are followed by the region name.
+	// The user code written between the markers of a region survives WriteFile.
+	Markers struct {
+		Begin string
+		End   string
+	}
+
+	// RegionError is a problem preventing the regions of a file from being merged.
+	RegionError struct {
+		// File is the path of the file holding the region, when known.
+		File string
+		Name string
+		// Line is the 1-based line of the problem in the existing file, or 0.
+		Line int
+		Msg  string
+	}
+
+	RegionErrors []RegionError
+
+	region struct {
+		line  int
+		start int
+		end   int
+	}
+
+	markersKey struct{}
+)
+
+// SetMarkers sets the markers used by Region and, within WriteFile, to merge the existing file.
+// The writer fails when a marker is blank or starts with the other one, as their lines could not be
+// told apart.
+func (w *Writer) SetMarkers(m Markers) {
+	begin, end := strings.TrimSpace(m.Begin), strings.TrimSpace(m.End)
+
+	if begin == "" || end == "" || strings.HasPrefix(begin, end) || strings.HasPrefix(end, begin) {
+		w.Fail(fmt.Errorf("invalid region markers %q and %q", m.Begin, m.End))
+		return
+	}
+
+	w.SetValue(markersKey{}, m)
+}
+
+// Markers returns the markers set by SetMarkers or DefaultMarkers.
+func (w *Writer) Markers() Markers {
+	if m, ok := w.Value(markersKey{}).(Markers); ok {
+		return m
+	}
+
+	return DefaultMarkers
+}
+
+// Region writes a region named name with the content written by f, leaving the writer at the end
+// of the closing marker. When WriteFile rewrites a file, its regions keep the content they have
+// in the existing file.
+func (w *Writer) Region(name string, f WriteFunc) {
+	if name == "" || strings.ContainsAny(name, "\r\n") {
+		w.Fail(fmt.Errorf("invalid region name '%s'", name))
+		return
+	}
+
+	m := w.Markers()
+
+	w.WriteString(m.Begin + name)
+	w.Newline()
+	f(w)
+
+	if !w.nl {
+		w.Newline()
+	}
+
+	w.WriteString(m.End + name)
+}
+
+// MergeRegions returns gen where the content of each region is replaced by the content of the region
+// with the same name in old. When a region of old does not exist in gen anymore, its content would be
+// lost; it is reported in the returned RegionErrors.
+func MergeRegions(old []byte, gen []byte, m Markers) ([]byte, error) {
+	prev, _, err := parseRegions(old, m)
+	if err != nil {
+		return nil, err
+	}
+
+	next, order, err := parseRegions(gen, m)
+	if err != nil {
+		return nil, fmt.Errorf("generated content: %w", err)
+	}
+
+	errs := RegionErrors{}
+
+	for name, reg := range prev {
+		if _, ok := next[name]; !ok {
+			errs = append(errs, RegionError{Name: name, Line: reg.line, Msg: "region does not exist in the generated content anymore"})
+		}
+	}
+
+	if len(errs) > 0 {
+		slices.SortFunc(errs, func(a, b RegionError) int { return a.Line - b.Line })
+		return nil, errs
+	}
+
+	res := bytes.Buffer{}
+	pos := 0
+
+	for _, name := range order {
+		reg := next[name]
+		res.Write(gen[pos:reg.start])
+
+		if p, ok := prev[name]; ok {
+			res.Write(old[p.start:p.end])
+		} else {
+			res.Write(gen[reg.start:reg.end])
+		}
+
+		pos = reg.end
+	}
+
+	res.Write(gen[pos:])
+	return res.Bytes(), nil
+}
+
+// parseRegions locates the regions of data, returning them by name along with their names in order.
+func parseRegions(data []byte, m Markers) (map[string]region, []string, error) {
+	begin := strings.TrimSpace(m.Begin)
+	end := strings.TrimSpace(m.End)
+
+	res := make(map[string]region)
+	order := []string(nil)
+	errs := RegionErrors{}
+
+	var (
+		cur  string
+		curr region
+	)
+
+	pos := 0
+
+	for line := 1; pos < len(data); line++ {
+		next := len(data)
+		if idx := bytes.IndexByte(data[pos:], '\n'); idx >= 0 {
+			next = pos + idx + 1
+		}
+
+		text := strings.TrimSpace(string(data[pos:next]))
+
+		switch {
+		case strings.HasPrefix(text, begin):
+			name := strings.TrimSpace(text[len(begin):])
+
+			if cur != "" {
+				errs = append(errs, RegionError{Name: name, Line: line, Msg: fmt.Sprintf("region begins within region '%s'", cur)})
+			} else if _, ok := res[name]; ok {
+				errs = append(errs, RegionError{Name: name, Line: line, Msg: "duplicate region"})
+			}
+
+			cur = name
+			curr = region{line: line, start: next}
+		case strings.HasPrefix(text, end):
+			name := strings.TrimSpace(text[len(end):])
+
+			if name != cur {
+				errs = append(errs, RegionError{Name: name, Line: line, Msg: "region ends without beginning"})
+				break
+			}
+
+			curr.end = pos
+			res[cur] = curr
+			order = append(order, cur)
+			cur = ""
+		}
+
+		pos = next
+	}
+
+	if cur != "" {
+		errs = append(errs, RegionError{Name: cur, Line: curr.line, Msg: "region does not end"})
+	}
+
+	if len(errs) > 0 {
+		return nil, nil, errs
+	}
+
+	return res, order, nil
+}
+
+func (e RegionError) Error() string {
+	res := e.Msg
+
+	if e.Name != "" {
+		res = fmt.Sprintf("region '%s': %s", e.Name, res)
+	}
+
+	if e.Line > 0 {
+		res = fmt.Sprintf("line %d: %s", e.Line, res)
+	}
+
+	if e.File != "" {
+		res = e.File + ": " + res
+	}
+
+	return res
+}
+
+func (e RegionErrors) Error() string {
+	items := make([]string, len(e))
+
+	for idx, itm := range e {
+		items[idx] = itm.Error()
+	}
+
+	return strings.Join(items, "\n")
+}
+
+// in returns the errors located in file.
+func (e RegionErrors) in(file string) RegionErrors {
+	res := make(RegionErrors, len(e))
+
+	for idx, itm := range e {
+		itm.File = file
+		res[idx] = itm
+	}
+
+	return res
+}
@@ -0,0 +1,76 @@
+package code_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	code "github.com/trwk76/go-code"
+)
+
+func TestWriteFileRegions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file.go")
+
+	gen := func(regions ...string) code.WriteFunc {
+		return func(w *code.Writer) {
+			w.WriteString("package file")
+			w.Newline()
+
+			for _, name := range regions {
+				w.Newline()
+				w.Region(name, func(w *code.Writer) {
+					w.WriteString("// TODO: " + name)
+				})
+				w.Newline()
+			}
+		}
+	}
+
+	if _, err := code.WriteFile(path, "", gen("Load", "Save")); err != nil {
+		t.Fatal(err)
+	}
+
+	edited := "package file\n\n// user code begin: Load\nfunc Load() {}\n// user code end: Load\n\n// user code begin: Save\n// TODO: Save\n// user code end: Save\n"
+
+	if err := os.WriteFile(path, []byte(edited), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := code.WriteFile(path, "", gen("Load", "Save", "Delete")); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := edited + "\n// user code begin: Delete\n// TODO: Delete\n// user code end: Delete\n"
+
+	if res, _ := os.ReadFile(path); string(res) != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, res)
+	}
+
+	_, err := code.WriteFile(path, "", gen("Save", "Delete"))
+
+	var errs code.RegionErrors
+
+	if !errors.As(err, &errs) || len(errs) != 1 || errs[0].Name != "Load" || errs[0].Line != 3 {
+		t.Fatalf("expected a conflict on region 'Load'; got: %v", err)
+	}
+
+	if res, _ := os.ReadFile(path); string(res) != expected {
+		t.Errorf("file was modified despite the conflict:\n%s", res)
+	}
+}
+
+func TestSetMarkers(t *testing.T) {
+	invalid := []code.Markers{
+		{},
+		{Begin: "// region ", End: " "},
+		{Begin: "// region ", End: "// region "},
+		{Begin: "//", End: "// end "},
+	}
+
+	for _, itm := range invalid {
+		if _, err := code.WriteStringErr("", func(w *code.Writer) { w.SetMarkers(itm) }); err == nil {
+			t.Errorf("expected markers %#v to be rejected", itm)
+		}
+	}
+}
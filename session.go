@@ -0,0 +1,284 @@
+package code
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ManifestName is the name of the file listing, at the root of a Session, the files it generated.
+const ManifestName = ".gocode-manifest"
+
+const manifestHeader = "# Files generated by github.com/trwk76/go-code; DO NOT EDIT.\n"
+
+type (
+	// Session generates a set of files under Root. Files are rendered in memory by WriteFile and
+	// written by Commit, which also deletes the files generated by the previous session that were
+	// not generated again. Generated files are tracked in the manifest file at the root.
+	Session struct {
+		Root      string
+		TabString string
+		// DryRun makes Commit report the changes without touching the disk.
+		DryRun bool
+		files  map[string]sessionFile
+	}
+
+	sessionFile struct {
+		content []byte
+		markers Markers
+	}
+
+	// manifestEntry is a file of the manifest, along with the markers delimiting its regions.
+	manifestEntry struct {
+		name    string
+		markers Markers
+	}
+
+	sessionPlan struct {
+		names []string
+		gens  map[string]generatedFile
+		stale []string
+		diffs FileDiffs
+	}
+)
+
+func NewSession(root string, tabString string) *Session {
+	return &Session{
+		Root:      root,
+		TabString: tabString,
+		files:     make(map[string]sessionFile),
+	}
+}
+
+// WriteFile renders the file at name, a slash-separated path relative to the root of the session.
+func (s *Session) WriteFile(name string, f WriteFunc) error {
+	if !filepath.IsLocal(filepath.FromSlash(name)) || name != path.Clean(name) || name == ManifestName || strings.ContainsAny(name, "\t\r\n") {
+		return fmt.Errorf("invalid generated file name '%s'", name)
+	}
+
+	if _, ok := s.files[name]; ok {
+		return fmt.Errorf("file '%s' already generated", name)
+	}
+
+	content, markers, err := render(s.TabString, f)
+	if err != nil {
+		return fmt.Errorf("rendering %s: %w", name, err)
+	}
+
+	s.files[name] = sessionFile{content: content, markers: markers}
+	return nil
+}
+
+// Commit writes the files of the session that changed, and deletes the files of the previous session
+// that were not generated again, unless DryRun is set. It returns the diffs of the files that changed,
+// sorted by path.
+// Nothing is written when the regions of an existing file cannot be preserved; the returned error is
+// then a RegionErrors.
+func (s *Session) Commit() (FileDiffs, error) {
+	plan, err := s.plan()
+	if err != nil || s.DryRun {
+		return plan.diffs, err
+	}
+
+	for _, name := range plan.names {
+		if gen, ok := plan.gens[name]; ok {
+			if err := writeAtomic(s.path(name), gen.content, gen.mode); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for _, name := range plan.stale {
+		if err := os.Remove(s.path(name)); err != nil {
+			return nil, err
+		}
+
+		s.removeEmptyDirs(path.Dir(name))
+	}
+
+	return plan.diffs, s.writeManifest(plan.names)
+}
+
+// Check compares the files of the session with the disk, without touching it, and returns the diffs
+// of the files Commit would change; they are empty when the generated files are up to date.
+func (s *Session) Check() (FileDiffs, error) {
+	plan, err := s.plan()
+	return plan.diffs, err
+}
+
+// plan computes the changes Commit makes.
+func (s *Session) plan() (sessionPlan, error) {
+	prev, err := s.readManifest()
+	if err != nil {
+		return sessionPlan{}, err
+	}
+
+	res := sessionPlan{
+		names: make([]string, 0, len(s.files)),
+		gens:  make(map[string]generatedFile),
+		diffs: FileDiffs{},
+	}
+
+	for name := range s.files {
+		res.names = append(res.names, name)
+	}
+
+	slices.Sort(res.names)
+
+	errs := RegionErrors{}
+
+	for _, name := range res.names {
+		file := s.files[name]
+
+		gen, err := prepareFile(s.path(name), file.content, file.markers)
+		if err != nil {
+			var rerrs RegionErrors
+
+			if errors.As(err, &rerrs) {
+				// report paths relative to the root
+				errs = append(errs, rerrs.in(name)...)
+				continue
+			}
+
+			return sessionPlan{}, err
+		}
+
+		if gen.op != 0 {
+			res.gens[name] = gen
+			res.diffs = append(res.diffs, diffFile(name, gen.op, gen.old, gen.content))
+		}
+	}
+
+	for _, entry := range prev {
+		name := entry.name
+
+		if _, ok := s.files[name]; ok {
+			continue
+		}
+
+		old, err := os.ReadFile(s.path(name))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return sessionPlan{}, err
+		}
+
+		// deleting the file would lose its user code
+		regs, _, err := parseRegions(old, entry.markers)
+		if err != nil {
+			var rerrs RegionErrors
+
+			errors.As(err, &rerrs)
+			errs = append(errs, rerrs.in(name)...)
+			continue
+		}
+
+		if len(regs) > 0 {
+			errs = append(errs, RegionError{File: name, Msg: "file holding user code is not generated anymore"})
+			continue
+		}
+
+		res.stale = append(res.stale, name)
+		res.diffs = append(res.diffs, diffFile(name, FileDeleted, old, nil))
+	}
+
+	if len(errs) > 0 {
+		return sessionPlan{}, errs
+	}
+
+	slices.SortFunc(res.diffs, func(a, b FileDiff) int { return strings.Compare(a.Path, b.Path) })
+	return res, nil
+}
+
+func (s *Session) path(name string) string {
+	return filepath.Join(s.Root, filepath.FromSlash(name))
+}
+
+// readManifest returns the files listed by the manifest. A file whose regions are not delimited by
+// DefaultMarkers is listed with its quoted markers, separated by tabs.
+func (s *Session) readManifest() ([]manifestEntry, error) {
+	data, err := os.ReadFile(s.path(ManifestName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	res := []manifestEntry{}
+	scn := bufio.NewScanner(bytes.NewReader(data))
+
+	for scn.Scan() {
+		line := strings.TrimSpace(scn.Text())
+
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		entry := manifestEntry{markers: DefaultMarkers}
+
+		fields := strings.Split(line, "\t")
+		entry.name = fields[0]
+
+		if len(fields) == 3 {
+			begin, berr := strconv.Unquote(fields[1])
+			end, eerr := strconv.Unquote(fields[2])
+
+			if berr != nil || eerr != nil {
+				return nil, fmt.Errorf("invalid markers of '%s' in %s", entry.name, ManifestName)
+			}
+
+			entry.markers = Markers{Begin: begin, End: end}
+		} else if len(fields) != 1 {
+			return nil, fmt.Errorf("invalid line '%s' in %s", line, ManifestName)
+		}
+
+		// never delete files out of the root, whatever the manifest says
+		if !filepath.IsLocal(filepath.FromSlash(entry.name)) {
+			return nil, fmt.Errorf("invalid file name '%s' in %s", entry.name, ManifestName)
+		}
+
+		res = append(res, entry)
+	}
+
+	return res, scn.Err()
+}
+
+func (s *Session) writeManifest(names []string) error {
+	buf := bytes.Buffer{}
+	buf.WriteString(manifestHeader)
+
+	for _, name := range names {
+		buf.WriteString(name)
+
+		if m := s.files[name].markers; m != DefaultMarkers {
+			buf.WriteString("\t" + strconv.Quote(m.Begin) + "\t" + strconv.Quote(m.End))
+		}
+
+		buf.WriteByte('\n')
+	}
+
+	name := s.path(ManifestName)
+
+	if old, err := os.ReadFile(name); err == nil && bytes.Equal(old, buf.Bytes()) {
+		return nil
+	}
+
+	return writeAtomic(name, buf.Bytes(), 0)
+}
+
+// removeEmptyDirs removes dir and its parents, up to the root, as long as they are empty.
+func (s *Session) removeEmptyDirs(dir string) {
+	for ; dir != "." && dir != "/"; dir = path.Dir(dir) {
+		if os.Remove(s.path(dir)) != nil {
+			return
+		}
+	}
+}
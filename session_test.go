@@ -0,0 +1,100 @@
+package code_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	code "github.com/trwk76/go-code"
+)
+
+func TestSession(t *testing.T) {
+	root := t.TempDir()
+
+	text := func(s string) code.WriteFunc {
+		return func(w *code.Writer) { w.WriteString(s) }
+	}
+
+	ses := code.NewSession(root, "")
+	ses.WriteFile("a.txt", text("one\ntwo\nthree\n"))
+	ses.WriteFile("sub/b.txt", text("b\n"))
+
+	if diffs, err := ses.Commit(); err != nil || len(diffs) != 2 {
+		t.Fatalf("expected 2 created files; got %v, %v", diffs, err)
+	}
+
+	ses = code.NewSession(root, "")
+	ses.DryRun = true
+	ses.WriteFile("a.txt", text("one\n2\nthree\n"))
+
+	diffs, err := ses.Commit()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "--- a/a.txt\n+++ b/a.txt\n@@ -1,3 +1,3 @@\n one\n-two\n+2\n three\n"
+
+	if len(diffs) != 2 || diffs[0].String() != expected || diffs[1].Path != "sub/b.txt" || diffs[1].Op != code.FileDeleted {
+		t.Fatalf("unexpected dry run diffs: %v", diffs)
+	}
+
+	if _, err := os.Stat(filepath.Join(root, "sub", "b.txt")); err != nil {
+		t.Errorf("dry run deleted a file: %v", err)
+	}
+
+	ses.DryRun = false
+
+	if _, err := ses.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	if diffs, err := ses.Check(); err != nil || len(diffs) != 0 {
+		t.Errorf("expected the files to be up to date; got %v, %v", diffs, err)
+	}
+
+	if _, err := os.Stat(filepath.Join(root, "sub")); !os.IsNotExist(err) {
+		t.Errorf("expected stale file and directory to be removed; got %v", err)
+	}
+
+	if res, _ := os.ReadFile(filepath.Join(root, "a.txt")); string(res) != "one\n2\nthree\n" {
+		t.Errorf("unexpected content: %q", res)
+	}
+
+	if err := ses.WriteFile("../out.txt", text("")); err == nil {
+		t.Errorf("expected files out of the root to be rejected")
+	}
+}
+
+func TestSessionUserCode(t *testing.T) {
+	root := t.TempDir()
+	markers := code.Markers{Begin: "# begin ", End: "# end "}
+
+	ses := code.NewSession(root, "")
+	ses.WriteFile("custom.txt", func(w *code.Writer) {
+		w.SetMarkers(markers)
+		w.Region("body", func(w *code.Writer) {})
+		w.Newline()
+	})
+	ses.WriteFile("broken.txt", func(w *code.Writer) { w.WriteString("generated\n") })
+
+	if _, err := ses.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	os.WriteFile(filepath.Join(root, "custom.txt"), []byte("# begin body\nuser code\n# end body\n"), 0644)
+	os.WriteFile(filepath.Join(root, "broken.txt"), []byte("// user code begin: body\nuser code\n"), 0644)
+
+	// neither file is generated anymore; both hold user code
+	var errs code.RegionErrors
+
+	if _, err := code.NewSession(root, "").Commit(); !errors.As(err, &errs) || len(errs) != 2 || errs[0].File != "broken.txt" || errs[1].File != "custom.txt" {
+		t.Fatalf("expected the files holding user code to be kept; got %v", err)
+	}
+
+	for _, name := range []string{"custom.txt", "broken.txt"} {
+		if _, err := os.Stat(filepath.Join(root, name)); err != nil {
+			t.Errorf("file holding user code was deleted: %v", err)
+		}
+	}
+}
@@ -53,14 +53,7 @@ func (gen *Generator) Finalize(spec spec.OpenAPI) {
 		gen.mapUnit.Decls = append(
 			gen.mapUnit.Decls,
 			g.FuncDecls{
-				g.FuncDecl{
-					ID: g.ID("Map"),
-					Params: g.Params{{
-						ID:   varMux.ID,
-						Type: g.PtrType{Item: mux},
-					}},
-					Body: gen.MapStmts,
-				},
+				g.Func("Map").Params(g.Param{ID: idMux, Type: g.PtrType{Item: mux}}).Body(gen.MapStmts...),
 			},
 		)
 	}
@@ -89,8 +82,9 @@ type (
 )
 
 var (
-	varMux         g.Symbol = g.Symbol{ID: g.ID("mux")}
-	funcHandleFunc g.ID     = g.ID("HandleFunc")
+	idMux          g.ID    = g.ID("mux")
+	varMux         g.Value = g.Ident(idMux)
+	funcHandleFunc g.ID    = g.ID("HandleFunc")
 )
 
 var (
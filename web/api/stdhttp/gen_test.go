@@ -41,7 +41,12 @@ func TestGen(t *testing.T) {
 
 	a.Generate(&gen)
 
-	fmt.Println(code.WriteString("\t", func(w *code.Writer) {
+	text, err := code.WriteStringErr("\t", func(w *code.Writer) {
 		unit.Write(w)
-	}))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fmt.Println(text)
 }
@@ -24,18 +24,10 @@ func (gen *Generator) Operation(path any, method string, o *api.Operation, spec
 
 	gen.MapStmts = append(
 		gen.MapStmts,
-		g.ExprStmt{
-			Expr: g.CallExpr{
-				Func: g.MemberExpr{
-					Value: varMux,
-					ID:    funcHandleFunc,
-				},
-				Args: g.Exprs{
-					g.StringExpr(fmt.Sprintf("%s %s", method, gen.opPath(gen.baseURL, pth.path))),
-					val,
-				},
-			},
-		},
+		varMux.Dot(funcHandleFunc).Call(
+			g.StringExpr(fmt.Sprintf("%s %s", method, gen.opPath(gen.baseURL, pth.path))),
+			val,
+		).Stmt(),
 	)
 }
 
@@ -3,44 +3,195 @@ package code
 import (
 	"bufio"
 	"bytes"
+	"errors"
+	"fmt"
 	"io"
+	"io/fs"
+	"math/rand/v2"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 )
 
 // WriteFile is a short hand for:
-// - ensuring that the given path directory exists.
-// - creating an empty file at the given path.
 // - writing the content of the file using the given WriteFunc.
-func WriteFile(path string, tabString string, f WriteFunc) error {
-	os.MkdirAll(filepath.Dir(path), 0777)
+// - preserving the content of the regions of the existing file (see Writer.Region).
+// - ensuring that the given path directory exists.
+// - replacing the file atomically, through a temporary file renamed to path.
+// The file is left untouched when its content would not change, or when anything fails: a panic
+// of the WriteFunc is returned as an error, and when a region of the existing file cannot be
+// preserved, the returned error is a RegionErrors.
+// WriteFile returns whether the file was written.
+func WriteFile(path string, tabString string, f WriteFunc) (bool, error) {
+	content, markers, err := render(tabString, f)
+	if err != nil {
+		return false, fmt.Errorf("rendering %s: %w", path, err)
+	}
+
+	gen, err := prepareFile(path, content, markers)
+	if err != nil || gen.op == 0 {
+		return false, err
+	}
 
-	fil, err := os.Create(path)
+	if err := writeAtomic(path, gen.content, gen.mode); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// CheckFile renders the content of a WriteFunc in memory and compares it with the file at path,
+// as WriteFile would write it, without touching the disk. It returns nil when the file is up to date.
+func CheckFile(path string, tabString string, f WriteFunc) (*FileDiff, error) {
+	content, markers, err := render(tabString, f)
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("rendering %s: %w", path, err)
+	}
+
+	gen, err := prepareFile(path, content, markers)
+	if err != nil || gen.op == 0 {
+		return nil, err
+	}
+
+	res := diffFile(path, gen.op, gen.old, gen.content)
+	return &res, nil
+}
+
+// generatedFile is the content of a file about to be written, merged with the existing file.
+type generatedFile struct {
+	op      FileOp
+	content []byte
+	old     []byte
+	mode    fs.FileMode // zero for a new file
+}
+
+// prepareFile merges content with the regions of the existing file at path; the returned op is
+// zero when the file is up to date.
+func prepareFile(path string, content []byte, markers Markers) (generatedFile, error) {
+	res := generatedFile{op: FileCreated, content: content}
+
+	old, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return res, nil
+	} else if err != nil {
+		return res, err
+	}
+
+	if res.content, err = MergeRegions(old, content, markers); err != nil {
+		var errs RegionErrors
+
+		if errors.As(err, &errs) {
+			err = errs.in(path)
+		}
+
+		return res, err
+	}
+
+	res.old = old
+
+	if bytes.Equal(old, res.content) {
+		res.op = 0
+		return res, nil
 	}
 
-	w := NewWriter(fil, tabString)
+	res.op = FileUpdated
+
+	if info, err := os.Stat(path); err == nil {
+		res.mode = info.Mode().Perm()
+	}
+
+	return res, nil
+}
 
+// render runs f on a writer to a buffer, returning a panic of f as an error.
+func render(tabString string, f WriteFunc) (res []byte, markers Markers, err error) {
 	defer func() {
-		w.Flush()
-		fil.Close()
+		if r := recover(); r != nil {
+			if rerr, ok := r.(error); ok {
+				err = rerr
+			} else {
+				err = fmt.Errorf("%v", r)
+			}
+		}
 	}()
 
+	buf := bytes.Buffer{}
+	w := NewWriter(&buf, tabString)
+
 	f(&w)
-	return nil
+
+	if err := w.Flush(); err != nil {
+		return nil, Markers{}, err
+	}
+
+	return buf.Bytes(), w.Markers(), nil
+}
+
+// writeAtomic replaces path with content through a temporary file; the file gets mode, or 0666
+// less the umask when mode is zero, as os.WriteFile would create it.
+func writeAtomic(path string, content []byte, mode fs.FileMode) error {
+	dir := filepath.Dir(path)
+
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		return err
+	}
+
+	tmp, err := createTemp(dir, filepath.Base(path))
+	if err != nil {
+		return err
+	}
+
+	name := tmp.Name()
+
+	if _, err = tmp.Write(content); err == nil && mode != 0 {
+		err = tmp.Chmod(mode)
+	}
+
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+
+	if err == nil {
+		err = os.Rename(name, path)
+	}
+
+	if err != nil {
+		os.Remove(name)
+	}
+
+	return err
+}
+
+// createTemp creates a new hidden file next to base in dir; unlike os.CreateTemp, its mode is
+// 0666 less the umask.
+func createTemp(dir string, base string) (*os.File, error) {
+	for try := 0; ; try++ {
+		name := filepath.Join(dir, "."+base+"."+strconv.FormatUint(uint64(rand.Uint32()), 10)+".tmp")
+
+		f, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0666)
+		if !errors.Is(err, fs.ErrExist) || try >= 10000 {
+			return f, err
+		}
+	}
 }
 
 // WriteString is a short hand for rendering the content of a WriteFunc as a string.
+// The error of the writer is ignored; see WriteStringErr.
 func WriteString(tabString string, f WriteFunc) string {
+	res, _ := WriteStringErr(tabString, f)
+	return res
+}
+
+// WriteStringErr is like WriteString but also returns the error of the writer, as reported by Flush.
+func WriteStringErr(tabString string, f WriteFunc) (string, error) {
 	buf := strings.Builder{}
 	w := NewWriter(&buf, tabString)
 
 	f(&w)
 
-	w.Flush()
-	return buf.String()
+	err := w.Flush()
+	return buf.String(), err
 }
 
 // NewWriter creates a new Writer that writes to the given io.Writer using the specified tabString for indentation.
@@ -55,20 +206,128 @@ func NewWriter(w io.Writer, tabString string) Writer {
 	}
 
 	return Writer{
-		w:   bw,
-		ts:  tabString,
-		nl:  true,
-		ind: 0,
+		w:    bw,
+		ts:   tabString,
+		nl:   true,
+		ind:  0,
+		line: 1,
+		vals: make(map[any]any),
+	}
+}
+
+// Capture renders the content of a WriteFunc as a string, using a writer that shares
+// the settings and values of w. An error of the capturing writer is recorded by w, at the
+// current position of w.
+func (w *Writer) Capture(f WriteFunc) string {
+	buf := strings.Builder{}
+	sub := NewWriter(&buf, w.ts)
+	sub.vals = w.vals
+	sub.maxw = w.maxw
+
+	f(&sub)
+
+	if err := sub.Flush(); err != nil && w.err == nil {
+		var perr *Error
+
+		if errors.As(err, &perr) {
+			// the captured text is expected to be written at the current position
+			res := &Error{Line: w.line + perr.Line - 1, Column: perr.Column, Err: perr.Err}
+
+			if perr.Line == 1 {
+				res.Column += w.col
+			}
+
+			w.err = res
+		} else {
+			w.Fail(err)
+		}
+	}
+
+	return buf.String()
+}
+
+// SetMaxWidth sets the width, in columns, that lines should not exceed; WriteFuncs wrap their output
+// accordingly when they can. Zero (the default) means no limit.
+func (w *Writer) SetMaxWidth(width int) {
+	w.maxw = width
+}
+
+// MaxWidth returns the width set by SetMaxWidth.
+func (w *Writer) MaxWidth() int {
+	return w.maxw
+}
+
+// Column returns the 0-based column at which the next character will be written, counting tabs
+// as TabWidth columns; pending indentation is included.
+func (w *Writer) Column() int {
+	if w.nl {
+		return int(w.ind) * textWidth([]byte(w.ts))
+	}
+
+	return w.vcol
+}
+
+// FitsLine returns whether the text written by f, followed by reserve more columns, fits on the
+// current line within the max line width. Nothing is written; errors raised by f are ignored.
+func (w *Writer) FitsLine(reserve int, f WriteFunc) bool {
+	if w.maxw < 1 {
+		return true
+	}
+
+	buf := strings.Builder{}
+	sub := NewWriter(&buf, w.ts)
+	sub.vals = w.vals
+	sub.maxw = w.maxw
+	sub.nl = false
+	sub.vcol = w.Column()
+
+	f(&sub)
+	sub.Flush()
+
+	return sub.line == 1 && sub.vcol+reserve <= w.maxw
+}
+
+// Err returns the first error that occurred while writing, if any. Once an error occurred,
+// the writer ignores further writes.
+func (w *Writer) Err() error {
+	return w.err
+}
+
+// Fail records err, located at the current position, unless an error already occurred.
+// It is meant for the WriteFuncs to report invalid input.
+func (w *Writer) Fail(err error) {
+	if w.err == nil && err != nil {
+		w.err = &Error{Line: w.line, Column: w.col + 1, Err: err}
 	}
 }
 
-// Flush will flush the underlying bufio.Writer.
+// Value returns the value associated with key through SetValue, or nil.
+func (w *Writer) Value(key any) any {
+	return w.vals[key]
+}
+
+// SetValue associates a value with key; values are visible to the writers created by Capture.
+// Callers should use their own unexported key types to avoid collisions.
+func (w *Writer) SetValue(key any, val any) {
+	w.vals[key] = val
+}
+
+// Flush will flush the underlying bufio.Writer and return the first error that occurred while writing.
 // Must be called before closing the underlying io.Writer if you want output to be complete.
 func (w *Writer) Flush() error {
-	return w.w.Flush()
+	if w.err != nil {
+		return w.err
+	}
+
+	w.err = w.w.Flush()
+	return w.err
 }
 
 func (w *Writer) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+
 	res := 0
 
 	for idx, line := range bytes.Split(p, []byte{'\n'}) {
@@ -76,16 +335,24 @@ func (w *Writer) Write(p []byte) (int, error) {
 			if err := w.Newline(); err != nil {
 				return res, err
 			}
+
+			res++
 		}
 
 		if len(line) > 0 {
-			w.ensureIndented()
+			if err := w.ensureIndented(); err != nil {
+				return res, err
+			}
 		}
 
-		if done, err := w.w.Write(line); err != nil {
+		done, err := w.w.Write(line)
+		res += done
+		w.col += done
+		w.vcol += textWidth(line[:done])
+
+		if err != nil {
+			w.err = err
 			return res, err
-		} else {
-			res += done
 		}
 	}
 
@@ -96,14 +363,74 @@ func (w *Writer) WriteString(s string) (int, error) {
 	return w.Write([]byte(s))
 }
 
+// WriteVerbatim writes s as is: unlike Write, it does not indent the lines following the first one,
+// which suits literals spanning several lines, such as raw strings.
+func (w *Writer) WriteVerbatim(s string) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+
+	if s == "" {
+		return 0, nil
+	}
+
+	if err := w.ensureIndented(); err != nil {
+		return 0, err
+	}
+
+	done, err := w.w.WriteString(s)
+	text := s[:done]
+
+	if idx := strings.LastIndexByte(text, '\n'); idx >= 0 {
+		w.line += strings.Count(text, "\n")
+		w.col = 0
+		w.vcol = 0
+		w.nl = idx == len(s)-1
+		text = text[idx+1:]
+	}
+
+	w.col += len(text)
+	w.vcol += textWidth([]byte(text))
+
+	if err != nil {
+		w.err = err
+	}
+
+	return done, err
+}
+
 func (w *Writer) WriteByte(b byte) error {
+	if w.err != nil {
+		return w.err
+	}
+
+	if b != '\n' {
+		if err := w.ensureIndented(); err != nil {
+			return err
+		}
+	}
+
+	if err := w.w.WriteByte(b); err != nil {
+		w.err = err
+		return err
+	}
+
 	if b == '\n' {
 		w.nl = true
+		w.line++
+		w.col = 0
+		w.vcol = 0
 	} else {
-		w.ensureIndented()
+		w.col++
+		w.vcol += textWidth([]byte{b})
 	}
 
-	return w.w.WriteByte(b)
+	return nil
+}
+
+// Line returns the 1-based number of the line being written.
+func (w *Writer) Line() int {
+	return w.line
 }
 
 func (w *Writer) Newline() error {
@@ -120,6 +447,18 @@ func (w *Writer) Indent(f WriteFunc) {
 	w.ind--
 }
 
+// Outdent runs f with one less level of indentation (ex: for labels in Go).
+func (w *Writer) Outdent(f WriteFunc) {
+	if w.ind < 1 {
+		f(w)
+		return
+	}
+
+	w.ind--
+	f(w)
+	w.ind++
+}
+
 func (w *Writer) Table(rows ...TableRow) {
 	colw := make([]int, 0)
 	maxw := 0
@@ -146,46 +485,51 @@ func (w *Writer) Table(rows ...TableRow) {
 
 	for _, row := range rows {
 		if row.Prefix != "" {
-			if _, err := w.WriteString(row.Prefix); err != nil {
-				panic(err)
-			}
+			w.WriteString(row.Prefix)
 
 			if !w.nl {
 				// Make sure we write the row bulk on a newline
-				if err := w.Newline(); err != nil {
-					panic(err)
-				}
+				w.Newline()
 			}
 		}
 
 		for idx, col := range row.Columns {
-			if _, err := w.WriteString(col); err != nil {
-				panic(err)
-			}
+			w.WriteString(col)
 
 			if idx < len(row.Columns)-1 {
-				if _, err := w.WriteString(pad[:colw[idx]-len(col)]); err != nil {
-					panic(err)
-				}
-
-				if err := w.WriteByte(' '); err != nil {
-					panic(err)
-				}
+				w.WriteString(pad[:colw[idx]-len(col)])
+				w.WriteByte(' ')
 			}
 		}
 
 		if err := w.Newline(); err != nil {
-			panic(err)
+			return
 		}
 	}
 }
 
+// TabWidth is the number of columns a tab counts for against the max line width.
+const TabWidth = 4
+
 type (
 	Writer struct {
-		w   *bufio.Writer
-		ts  string
-		nl  bool
-		ind uint16
+		w    *bufio.Writer
+		ts   string
+		nl   bool
+		ind  uint16
+		line int
+		col  int
+		vcol int
+		maxw int
+		vals map[any]any
+		err  error
+	}
+
+	// Error is an error located in the output of a Writer; Line and Column are 1-based.
+	Error struct {
+		Line   int
+		Column int
+		Err    error
 	}
 
 	TableRow struct {
@@ -196,16 +540,51 @@ type (
 	WriteFunc func(w *Writer)
 )
 
-func (w *Writer) ensureIndented() {
+func (e *Error) Error() string {
+	return fmt.Sprintf("%d:%d: %s", e.Line, e.Column, e.Err.Error())
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func (w *Writer) ensureIndented() error {
+	if w.err != nil {
+		return w.err
+	}
+
 	if !w.nl {
-		return
+		return nil
 	}
 
 	for range w.ind {
-		if _, err := w.w.WriteString(w.ts); err != nil {
-			panic(err)
+		done, err := w.w.WriteString(w.ts)
+		w.col += done
+		w.vcol += textWidth([]byte(w.ts[:done]))
+
+		if err != nil {
+			w.err = err
+			return err
 		}
 	}
 
 	w.nl = false
+	return nil
+}
+
+// textWidth returns the number of columns taken by text.
+func textWidth(text []byte) int {
+	res := 0
+
+	for _, b := range text {
+		switch {
+		case b == '\t':
+			res += TabWidth
+		case b&0xC0 != 0x80:
+			// not a UTF-8 continuation byte
+			res++
+		}
+	}
+
+	return res
 }
@@ -1,7 +1,11 @@
 package code_test
 
 import (
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	code "github.com/trwk76/go-code"
@@ -13,6 +17,153 @@ func TestWriter(t *testing.T) {
 	}
 }
 
+func TestWriteFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "sub", "file.txt")
+
+	content := func(s string) code.WriteFunc {
+		return func(w *code.Writer) { w.WriteString(s) }
+	}
+
+	if changed, err := code.WriteFile(path, "", content("hello")); err != nil || !changed {
+		t.Fatalf("expected the file to be created; got changed=%v, err=%v", changed, err)
+	}
+
+	ref := filepath.Join(dir, "ref.txt")
+	os.WriteFile(ref, nil, 0666)
+
+	if got, want := fileMode(path), fileMode(ref); got != want {
+		t.Errorf("expected a new file to get mode %v like os.WriteFile; got %v", want, got)
+	}
+
+	os.Chmod(path, 0600)
+	mode := fileMode(path)
+
+	if changed, err := code.WriteFile(path, "", content("hello")); err != nil || changed {
+		t.Errorf("expected the file to be left untouched; got changed=%v, err=%v", changed, err)
+	}
+
+	changed, err := code.WriteFile(path, "", func(w *code.Writer) {
+		w.WriteString("partial")
+		panic(errors.New("failure"))
+	})
+
+	if err == nil || changed {
+		t.Errorf("expected a panic to be reported as an error; got changed=%v, err=%v", changed, err)
+	}
+
+	if res, _ := os.ReadFile(path); string(res) != "hello" {
+		t.Errorf("file was modified by a failed write: %q", res)
+	}
+
+	if changed, err := code.WriteFile(path, "", content("world")); err != nil || !changed {
+		t.Errorf("expected the file to be replaced; got changed=%v, err=%v", changed, err)
+	}
+
+	if got := fileMode(path); got != mode {
+		t.Errorf("expected the replaced file to keep mode %v; got %v", mode, got)
+	}
+
+	if entries, _ := os.ReadDir(filepath.Dir(path)); len(entries) != 1 {
+		t.Errorf("expected temporary files to be removed; got %d entries", len(entries))
+	}
+}
+
+func fileMode(path string) os.FileMode {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0
+	}
+
+	return info.Mode().Perm()
+}
+
+func TestCheckFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file.txt")
+
+	lines := func(vals ...string) code.WriteFunc {
+		return func(w *code.Writer) {
+			for _, val := range vals {
+				w.WriteString(val)
+				w.Newline()
+			}
+		}
+	}
+
+	if diff, err := code.CheckFile(path, "", lines("a")); err != nil || diff == nil || diff.Op != code.FileCreated {
+		t.Fatalf("expected a missing file to be reported; got %v, %v", diff, err)
+	}
+
+	code.WriteFile(path, "", lines("1", "2", "3", "4", "5", "6", "7", "8", "9", "10", "11", "12"))
+
+	if diff, err := code.CheckFile(path, "", lines("1", "2", "3", "4", "5", "6", "7", "8", "9", "10", "11", "12")); err != nil || diff != nil {
+		t.Errorf("expected an up to date file; got %v, %v", diff, err)
+	}
+
+	diff, err := code.CheckFile(path, "", lines("one", "2", "3", "4", "5", "6", "7", "8", "9", "10", "11", "twelve"))
+	if err != nil || diff == nil {
+		t.Fatalf("expected a diff; got %v, %v", diff, err)
+	}
+
+	expected := "--- " + path + "\n+++ " + path + "\n" +
+		"@@ -1,4 +1,4 @@\n-1\n+one\n 2\n 3\n 4\n" +
+		"@@ -9,4 +9,4 @@\n 9\n 10\n 11\n-12\n+twelve\n"
+
+	if res := diff.String(); res != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, res)
+	}
+
+	if res, _ := os.ReadFile(path); string(res) != "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n" {
+		t.Errorf("check modified the file: %q", res)
+	}
+}
+
+func TestWriterError(t *testing.T) {
+	fail := errors.New("disk full")
+	w := code.NewWriter(failingWriter{err: fail}, "")
+
+	// exceed the buffer size so that the underlying writer is called
+	for range 1000 {
+		w.WriteString("some content")
+		w.Newline()
+	}
+
+	if _, err := w.WriteString("more"); !errors.Is(err, fail) {
+		t.Errorf("expected writes to fail once an error occurred; got %v", err)
+	}
+
+	if err := w.Flush(); !errors.Is(err, fail) {
+		t.Errorf("expected flush to report the error; got %v", err)
+	}
+
+	w = code.NewWriter(&strings.Builder{}, "")
+	w.WriteString("line")
+	w.Newline()
+	w.Indent(func(w *code.Writer) {
+		w.WriteString("a := ")
+		w.Fail(errors.New("invalid"))
+		w.WriteString("ignored")
+	})
+
+	var werr *code.Error
+
+	if err := w.Flush(); !errors.As(err, &werr) || werr.Line != 2 || werr.Column != 7 {
+		t.Errorf("expected an error at 2:7; got %v", err)
+	}
+
+	if _, err := code.WriteStringErr("", func(w *code.Writer) { w.Fail(errors.New("invalid")) }); !errors.As(err, &werr) {
+		t.Errorf("expected WriteStringErr to report the error; got %v", err)
+	}
+}
+
+type failingWriter struct {
+	err error
+}
+
+func (w failingWriter) Write(p []byte) (int, error) {
+	return 0, w.err
+}
+
 type (
 	writerTest struct {
 		f   code.WriteFunc
@@ -21,7 +172,10 @@ type (
 )
 
 func (i writerTest) test(t *testing.T) {
-	res := code.WriteString("", i.f)
+	res, err := code.WriteStringErr("", i.f)
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	if res != i.res {
 		t.Errorf("expected:\n%s\ngot:\n%s\n", i.res, res)